@@ -4,16 +4,31 @@ import (
 	"database/sql"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/alissoncorsair/appsolidario-backend/config"
 	"github.com/alissoncorsair/appsolidario-backend/payment"
+	"github.com/alissoncorsair/appsolidario-backend/service/account_deletion"
+	"github.com/alissoncorsair/appsolidario-backend/service/auth"
+	"github.com/alissoncorsair/appsolidario-backend/service/campaign"
+	"github.com/alissoncorsair/appsolidario-backend/service/data_export"
+	"github.com/alissoncorsair/appsolidario-backend/service/follow"
+	"github.com/alissoncorsair/appsolidario-backend/service/gamification"
+	"github.com/alissoncorsair/appsolidario-backend/service/leaderboard"
+	"github.com/alissoncorsair/appsolidario-backend/service/login_attempt"
 	"github.com/alissoncorsair/appsolidario-backend/service/mailer"
+	"github.com/alissoncorsair/appsolidario-backend/service/mfa"
+	"github.com/alissoncorsair/appsolidario-backend/service/moderation"
 	"github.com/alissoncorsair/appsolidario-backend/service/notification"
 	paymentService "github.com/alissoncorsair/appsolidario-backend/service/payment"
 	"github.com/alissoncorsair/appsolidario-backend/service/post"
 	"github.com/alissoncorsair/appsolidario-backend/service/profile_picture"
+	"github.com/alissoncorsair/appsolidario-backend/service/search"
+	"github.com/alissoncorsair/appsolidario-backend/service/session"
+	"github.com/alissoncorsair/appsolidario-backend/service/token"
 	"github.com/alissoncorsair/appsolidario-backend/service/transactions"
 	"github.com/alissoncorsair/appsolidario-backend/service/user"
+	"github.com/alissoncorsair/appsolidario-backend/service/verification"
 	"github.com/alissoncorsair/appsolidario-backend/storage"
 	"github.com/alissoncorsair/appsolidario-backend/utils"
 )
@@ -53,19 +68,52 @@ func (s *APIServer) Run() error {
 	userStore := user.NewStore(s.db)
 	profilePictureStore := profile_picture.NewStore(s.db)
 	notificationStore := notification.NewStore(s.db)
-	userHandler := user.NewHandler(userStore, profilePictureStore, notificationStore, s.storage, mailer)
+	tokenStore := token.NewStore(s.db)
+	sessionStore := session.NewStore(s.db)
+	loginAttemptStore := login_attempt.NewStore(s.db)
+	mfaStore := mfa.NewStore(s.db)
+	leaderboardStore := leaderboard.NewStore(s.db)
+	gamificationStore := gamification.NewStore(s.db)
+	gamificationEngine := gamification.NewEngine(gamificationStore, notificationStore, leaderboardStore)
+	userHandler := user.NewHandler(userStore, profilePictureStore, notificationStore, tokenStore, sessionStore, loginAttemptStore, mfaStore, s.storage, mailer, gamificationEngine)
 	userHandler.RegisterRoutes(apiRouter)
 	postStore := post.NewStore(s.db)
-	postHandler := post.NewHandler(postStore, userStore, s.storage)
+	postHandler := post.NewHandler(postStore, userStore, sessionStore, notificationStore, s.storage, gamificationEngine)
 	postHandler.RegisterRoutes(apiRouter)
+	searchHandler := search.NewHandler(userStore, postStore, sessionStore)
+	searchHandler.RegisterRoutes(apiRouter)
+	followHandler := follow.NewHandler(follow.NewStore(s.db), userStore, notificationStore, sessionStore)
+	followHandler.RegisterRoutes(apiRouter)
+	gamificationHandler := gamification.NewHandler(gamificationStore, gamificationEngine, userStore, sessionStore)
+	gamificationHandler.RegisterRoutes(apiRouter)
+	leaderboardHandler := leaderboard.NewHandler(leaderboardStore, userStore, sessionStore)
+	leaderboardHandler.RegisterRoutes(apiRouter)
 	transactionsStore := transactions.NewStore(s.db)
+	campaignStore := campaign.NewStore(s.db)
+	campaignHandler := campaign.NewHandler(campaignStore, userStore, sessionStore, s.storage)
+	campaignHandler.RegisterRoutes(apiRouter)
 	paymentStore := paymentService.NewStore(s.db, payment.MercadoPago{
 		AccessToken: config.Envs.MercadoPagoAccessToken,
-	}, transactionsStore, userStore, notificationStore, mailer)
-	paymentHandler := paymentService.NewHandler(paymentStore, userStore)
+	}, transactionsStore, userStore, notificationStore, mailer, gamificationEngine, leaderboardStore, campaignStore, postStore)
+	paymentHandler := paymentService.NewHandler(paymentStore, userStore, sessionStore)
 
 	paymentHandler.RegisterRoutes(apiRouter)
-
+	verificationHandler := verification.NewHandler(verification.NewStore(s.db), userStore, notificationStore, sessionStore, s.storage, mailer)
+	verificationHandler.RegisterRoutes(apiRouter)
+	moderationHandler := moderation.NewHandler(moderation.NewStore(s.db), userStore, sessionStore)
+	moderationHandler.RegisterRoutes(apiRouter)
+	dataExportStore := data_export.NewStore(s.db)
+	dataExportHandler := data_export.NewHandler(dataExportStore, data_export.NewExporter(dataExportStore, s.storage), userStore, sessionStore, s.storage)
+	dataExportHandler.RegisterRoutes(apiRouter)
+	accountDeletionStore := account_deletion.NewStore(s.db)
+	accountDeletionHandler := account_deletion.NewHandler(accountDeletionStore, userStore, sessionStore)
+	accountDeletionHandler.RegisterRoutes(apiRouter)
+
+	go account_deletion.NewWorker(accountDeletionStore, s.storage).Run(time.Hour)
+	go campaign.NewWorker(campaignStore, notificationStore).Run(time.Minute)
+	go data_export.NewWorker(dataExportStore, s.storage).Run(time.Hour)
+
+	router.HandleFunc("GET /.well-known/jwks.json", auth.HandleJWKS)
 	router.Handle("/api/", corsMiddleware(http.StripPrefix("/api", apiRouter)))
 
 	server := http.Server{
@@ -81,7 +129,7 @@ func (s *APIServer) Run() error {
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		if r.Method == "OPTIONS" {
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/alissoncorsair/appsolidario-backend/geo"
+)
+
+// Regenerates geo/data/municipalities.csv with every IBGE municipality. The
+// source is a CSV with the IBGE code, name, coordinates and state code of
+// each municipality, like the one kept at github.com/kelvins/municipios-brasileiros.
+// Run "migrate up" afterwards to load the new file into the database.
+func main() {
+	source := flag.String("source", "https://raw.githubusercontent.com/kelvins/municipios-brasileiros/main/csv/municipios.csv", "URL of the municipalities CSV")
+	out := flag.String("out", "geo/data/municipalities.csv", "file to write")
+	flag.Parse()
+
+	client := &http.Client{Timeout: time.Minute}
+	resp, err := client.Get(*source)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Fatalf("failed to download %s: %s", *source, resp.Status)
+	}
+
+	records, err := read(resp.Body)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(records) < geo.MinMunicipalities {
+		log.Fatalf("the source has %d municipalities, expected at least %d", len(records), geo.MinMunicipalities)
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"code", "name", "state_code", "latitude", "longitude"})
+	writer.WriteAll(records)
+
+	if err := writer.Error(); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("wrote %d municipalities to %s", len(records), *out)
+}
+
+// read picks the columns we need by name, so the column order of the source
+// doesn't matter, and sorts the municipalities by code.
+func read(r io.Reader) ([][]string, error) {
+	reader := csv.NewReader(r)
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[name] = i
+	}
+
+	wanted := []string{"codigo_ibge", "nome", "codigo_uf", "latitude", "longitude"}
+	for _, name := range wanted {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %s missing from the source", name)
+		}
+	}
+
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		row := make([]string, len(wanted))
+		for i, name := range wanted {
+			row[i] = record[columns[name]]
+		}
+
+		if _, err := strconv.Atoi(row[0]); err != nil {
+			return nil, fmt.Errorf("invalid municipality code %q", row[0])
+		}
+
+		records = append(records, row)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		a, _ := strconv.Atoi(records[i][0])
+		b, _ := strconv.Atoi(records[j][0])
+		return a < b
+	})
+
+	return records, nil
+}
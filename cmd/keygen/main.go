@@ -0,0 +1,54 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alissoncorsair/appsolidario-backend/config"
+)
+
+// Generates a new Ed25519 key for signing JWTs. The file name is the key id,
+// so by default the newest key is the one picked for signing.
+func main() {
+	dir := flag.String("dir", config.Envs.JWTKeysDir, "directory where the key is written")
+	id := flag.String("id", time.Now().UTC().Format("20060102150405"), "key id")
+	flag.Parse()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.MkdirAll(*dir, 0700); err != nil {
+		log.Fatal(err)
+	}
+
+	path := filepath.Join(*dir, *id+".pem")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer file.Close()
+
+	if err := pem.Encode(file, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("key written to %s", path)
+}
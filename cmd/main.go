@@ -7,10 +7,18 @@ import (
 	"github.com/alissoncorsair/appsolidario-backend/cmd/api"
 	"github.com/alissoncorsair/appsolidario-backend/config"
 	"github.com/alissoncorsair/appsolidario-backend/db"
+	"github.com/alissoncorsair/appsolidario-backend/service/auth"
 )
 
 func main() {
 	cfg := config.Envs
+
+	err := auth.InitKeys(cfg.JWTKeysDir, cfg.JWTSigningKeyID, cfg.DevMode)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	db, err := db.NewPostgreSQLStorage(*cfg)
 
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 
 	"github.com/alissoncorsair/appsolidario-backend/config"
 	"github.com/alissoncorsair/appsolidario-backend/db"
+	"github.com/alissoncorsair/appsolidario-backend/geo"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
@@ -43,6 +44,11 @@ func main() {
 		if err != nil && err != migrate.ErrNoChange {
 			log.Fatal(err)
 		}
+
+		// the IBGE tables follow geo/data, which changes without migrations
+		if err := geo.Seed(db); err != nil {
+			log.Fatal(err)
+		}
 	case "down":
 		err = m.Down()
 		if err != nil && err != migrate.ErrNoChange {
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_tokens_user_id_type;
+DROP INDEX IF EXISTS idx_tokens_token;
+
+ALTER TABLE tokens
+DROP COLUMN IF EXISTS revoked_at;
@@ -0,0 +1,5 @@
+ALTER TABLE tokens
+ADD COLUMN revoked_at TIMESTAMP;
+
+CREATE UNIQUE INDEX idx_tokens_token ON tokens(token);
+CREATE INDEX idx_tokens_user_id_type ON tokens(user_id, type);
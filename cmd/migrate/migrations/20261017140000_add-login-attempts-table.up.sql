@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS login_attempts (
+  key VARCHAR(100) PRIMARY KEY,
+  failures INT NOT NULL DEFAULT 0,
+  last_failure_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  blocked_until TIMESTAMP
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS account_deletions;
+
+DELETE FROM users WHERE cpf = '00000000000'
+  AND NOT EXISTS (SELECT 1 FROM transactions WHERE payer_id = users.id OR payee_id = users.id)
+  AND NOT EXISTS (SELECT 1 FROM notifications WHERE from_user_id = users.id);
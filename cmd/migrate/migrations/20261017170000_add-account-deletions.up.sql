@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS account_deletions (
+  user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  requested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  scheduled_for TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_account_deletions_scheduled_for ON account_deletions(scheduled_for);
+
+-- Financial records must outlive the people in them, so the transactions
+-- and notifications of a deleted user are moved to this placeholder. The CPF
+-- is not a valid one, so nobody can register or log in with it.
+INSERT INTO users (name, surname, email, password, status, city, state, cpf, role_id, birth_date)
+VALUES ('Usuário', 'removido', 'removido@solidariza.invalid', '', 0, '', '', '00000000000', 0, '1900-01-01')
+ON CONFLICT DO NOTHING;
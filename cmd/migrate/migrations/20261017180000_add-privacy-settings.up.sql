@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS privacy_settings (
+  user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  show_city BOOLEAN NOT NULL DEFAULT TRUE,
+  show_state BOOLEAN NOT NULL DEFAULT TRUE,
+  show_description BOOLEAN NOT NULL DEFAULT TRUE,
+  show_points BOOLEAN NOT NULL DEFAULT TRUE,
+  show_birth_date BOOLEAN NOT NULL DEFAULT FALSE,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
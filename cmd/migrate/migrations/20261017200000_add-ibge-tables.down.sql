@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN IF EXISTS city_ibge_code;
+
+DROP TABLE IF EXISTS ibge_municipalities;
+DROP TABLE IF EXISTS ibge_states;
@@ -0,0 +1,154 @@
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- Only a starting point, migrate up loads the full geo/data/*.csv through
+-- geo.Seed.
+CREATE TABLE IF NOT EXISTS ibge_states (
+  code SMALLINT PRIMARY KEY,
+  uf CHAR(2) UNIQUE NOT NULL,
+  name VARCHAR(50) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ibge_municipalities (
+  code INT PRIMARY KEY,
+  name VARCHAR(100) NOT NULL,
+  state_code SMALLINT NOT NULL REFERENCES ibge_states(code)
+);
+
+CREATE INDEX idx_ibge_municipalities_state_code ON ibge_municipalities(state_code);
+
+INSERT INTO ibge_states (code, uf, name) VALUES
+  (11, 'RO', 'Rondônia'),
+  (12, 'AC', 'Acre'),
+  (13, 'AM', 'Amazonas'),
+  (14, 'RR', 'Roraima'),
+  (15, 'PA', 'Pará'),
+  (16, 'AP', 'Amapá'),
+  (17, 'TO', 'Tocantins'),
+  (21, 'MA', 'Maranhão'),
+  (22, 'PI', 'Piauí'),
+  (23, 'CE', 'Ceará'),
+  (24, 'RN', 'Rio Grande do Norte'),
+  (25, 'PB', 'Paraíba'),
+  (26, 'PE', 'Pernambuco'),
+  (27, 'AL', 'Alagoas'),
+  (28, 'SE', 'Sergipe'),
+  (29, 'BA', 'Bahia'),
+  (31, 'MG', 'Minas Gerais'),
+  (32, 'ES', 'Espírito Santo'),
+  (33, 'RJ', 'Rio de Janeiro'),
+  (35, 'SP', 'São Paulo'),
+  (41, 'PR', 'Paraná'),
+  (42, 'SC', 'Santa Catarina'),
+  (43, 'RS', 'Rio Grande do Sul'),
+  (50, 'MS', 'Mato Grosso do Sul'),
+  (51, 'MT', 'Mato Grosso'),
+  (52, 'GO', 'Goiás'),
+  (53, 'DF', 'Distrito Federal')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO ibge_municipalities (code, name, state_code) VALUES
+  (1100122, 'Ji-Paraná', 11),
+  (1100205, 'Porto Velho', 11),
+  (1200203, 'Cruzeiro do Sul', 12),
+  (1200401, 'Rio Branco', 12),
+  (1302603, 'Manaus', 13),
+  (1303403, 'Parintins', 13),
+  (1400100, 'Boa Vista', 14),
+  (1500800, 'Ananindeua', 15),
+  (1501402, 'Belém', 15),
+  (1506807, 'Santarém', 15),
+  (1600303, 'Macapá', 16),
+  (1600600, 'Santana', 16),
+  (1702109, 'Araguaína', 17),
+  (1721000, 'Palmas', 17),
+  (2105302, 'Imperatriz', 21),
+  (2111300, 'São Luís', 21),
+  (2207702, 'Parnaíba', 22),
+  (2211001, 'Teresina', 22),
+  (2303709, 'Caucaia', 23),
+  (2304400, 'Fortaleza', 23),
+  (2307304, 'Juazeiro do Norte', 23),
+  (2403251, 'Parnamirim', 24),
+  (2408003, 'Mossoró', 24),
+  (2408102, 'Natal', 24),
+  (2504009, 'Campina Grande', 25),
+  (2507507, 'João Pessoa', 25),
+  (2604106, 'Caruaru', 26),
+  (2607901, 'Jaboatão dos Guararapes', 26),
+  (2609600, 'Olinda', 26),
+  (2611101, 'Petrolina', 26),
+  (2611606, 'Recife', 26),
+  (2700300, 'Arapiraca', 27),
+  (2704302, 'Maceió', 27),
+  (2800308, 'Aracaju', 28),
+  (2804805, 'Nossa Senhora do Socorro', 28),
+  (2910800, 'Feira de Santana', 29),
+  (2927408, 'Salvador', 29),
+  (2933307, 'Vitória da Conquista', 29),
+  (3106200, 'Belo Horizonte', 31),
+  (3106705, 'Betim', 31),
+  (3118601, 'Contagem', 31),
+  (3136702, 'Juiz de Fora', 31),
+  (3170206, 'Uberlândia', 31),
+  (3201308, 'Cariacica', 32),
+  (3205002, 'Serra', 32),
+  (3205200, 'Vila Velha', 32),
+  (3205309, 'Vitória', 32),
+  (3301702, 'Duque de Caxias', 33),
+  (3303302, 'Niterói', 33),
+  (3303500, 'Nova Iguaçu', 33),
+  (3304557, 'Rio de Janeiro', 33),
+  (3304904, 'São Gonçalo', 33),
+  (3509502, 'Campinas', 35),
+  (3518800, 'Guarulhos', 35),
+  (3534401, 'Osasco', 35),
+  (3543402, 'Ribeirão Preto', 35),
+  (3547809, 'Santo André', 35),
+  (3548500, 'Santos', 35),
+  (3548708, 'São Bernardo do Campo', 35),
+  (3549904, 'São José dos Campos', 35),
+  (3550308, 'São Paulo', 35),
+  (3552205, 'Sorocaba', 35),
+  (4106902, 'Curitiba', 41),
+  (4113700, 'Londrina', 41),
+  (4115200, 'Maringá', 41),
+  (4119905, 'Ponta Grossa', 41),
+  (4202404, 'Blumenau', 42),
+  (4205407, 'Florianópolis', 42),
+  (4209102, 'Joinville', 42),
+  (4304606, 'Canoas', 43),
+  (4305108, 'Caxias do Sul', 43),
+  (4314407, 'Pelotas', 43),
+  (4314902, 'Porto Alegre', 43),
+  (5002704, 'Campo Grande', 50),
+  (5003702, 'Dourados', 50),
+  (5103403, 'Cuiabá', 51),
+  (5108402, 'Várzea Grande', 51),
+  (5201108, 'Anápolis', 52),
+  (5201405, 'Aparecida de Goiânia', 52),
+  (5208707, 'Goiânia', 52),
+  (5300108, 'Brasília', 53)
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users
+ADD COLUMN city_ibge_code INT REFERENCES ibge_municipalities(code);
+
+CREATE INDEX idx_users_city_ibge_code ON users(city_ibge_code);
+
+-- Canonicalize what users typed so far: states become their UF and known
+-- cities get the IBGE spelling and code. Unknown cities are only trimmed.
+UPDATE users u
+SET state = s.uf
+FROM ibge_states s
+WHERE lower(unaccent(trim(u.state))) IN (lower(unaccent(s.name)), lower(s.uf));
+
+UPDATE users u
+SET city = m.name, city_ibge_code = m.code
+FROM ibge_municipalities m
+JOIN ibge_states s ON s.code = m.state_code
+WHERE u.state = s.uf
+  AND lower(unaccent(trim(regexp_replace(u.city, '[\s''-]+', ' ', 'g')))) = lower(unaccent(trim(regexp_replace(m.name, '[\s''-]+', ' ', 'g'))));
+
+UPDATE users
+SET city = regexp_replace(trim(city), '\s+', ' ', 'g')
+WHERE city_ibge_code IS NULL;
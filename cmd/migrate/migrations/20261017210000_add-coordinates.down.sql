@@ -0,0 +1,7 @@
+ALTER TABLE users
+DROP COLUMN IF EXISTS latitude,
+DROP COLUMN IF EXISTS longitude;
+
+ALTER TABLE ibge_municipalities
+DROP COLUMN IF EXISTS latitude,
+DROP COLUMN IF EXISTS longitude;
@@ -0,0 +1,104 @@
+ALTER TABLE ibge_municipalities
+ADD COLUMN latitude DOUBLE PRECISION,
+ADD COLUMN longitude DOUBLE PRECISION;
+
+-- Only a starting point, migrate up loads the full geo/data/municipalities.csv
+-- through geo.Seed.
+UPDATE ibge_municipalities m
+SET latitude = c.latitude, longitude = c.longitude
+FROM (VALUES
+  (1100122, -10.88, -61.95),
+  (1100205, -8.76, -63.90),
+  (1200203, -7.63, -72.67),
+  (1200401, -9.97, -67.81),
+  (1302603, -3.12, -60.02),
+  (1303403, -2.63, -56.74),
+  (1400100, 2.82, -60.67),
+  (1500800, -1.37, -48.37),
+  (1501402, -1.46, -48.50),
+  (1506807, -2.44, -54.71),
+  (1600303, 0.03, -51.07),
+  (1600600, -0.06, -51.17),
+  (1702109, -7.19, -48.21),
+  (1721000, -10.18, -48.33),
+  (2105302, -5.52, -47.48),
+  (2111300, -2.53, -44.30),
+  (2207702, -2.90, -41.78),
+  (2211001, -5.09, -42.80),
+  (2303709, -3.74, -38.66),
+  (2304400, -3.73, -38.53),
+  (2307304, -7.21, -39.32),
+  (2403251, -5.92, -35.26),
+  (2408003, -5.19, -37.34),
+  (2408102, -5.79, -35.21),
+  (2504009, -7.23, -35.88),
+  (2507507, -7.12, -34.86),
+  (2604106, -8.28, -35.98),
+  (2607901, -8.11, -35.01),
+  (2609600, -8.01, -34.86),
+  (2611101, -9.39, -40.50),
+  (2611606, -8.05, -34.88),
+  (2700300, -9.75, -36.66),
+  (2704302, -9.67, -35.74),
+  (2800308, -10.91, -37.07),
+  (2804805, -10.86, -37.13),
+  (2910800, -12.27, -38.97),
+  (2927408, -12.97, -38.50),
+  (2933307, -14.86, -40.84),
+  (3106200, -19.92, -43.94),
+  (3106705, -19.97, -44.20),
+  (3118601, -19.93, -44.05),
+  (3136702, -21.76, -43.35),
+  (3170206, -18.92, -48.28),
+  (3201308, -20.26, -40.42),
+  (3205002, -20.13, -40.31),
+  (3205200, -20.33, -40.29),
+  (3205309, -20.32, -40.34),
+  (3301702, -22.79, -43.31),
+  (3303302, -22.88, -43.10),
+  (3303500, -22.76, -43.45),
+  (3304557, -22.91, -43.17),
+  (3304904, -22.83, -43.05),
+  (3509502, -22.91, -47.06),
+  (3518800, -23.46, -46.53),
+  (3534401, -23.53, -46.79),
+  (3543402, -21.18, -47.81),
+  (3547809, -23.66, -46.53),
+  (3548500, -23.96, -46.33),
+  (3548708, -23.69, -46.56),
+  (3549904, -23.18, -45.89),
+  (3550308, -23.55, -46.63),
+  (3552205, -23.50, -47.46),
+  (4106902, -25.43, -49.27),
+  (4113700, -23.31, -51.16),
+  (4115200, -23.42, -51.94),
+  (4119905, -25.09, -50.16),
+  (4202404, -26.92, -49.07),
+  (4205407, -27.60, -48.55),
+  (4209102, -26.30, -48.85),
+  (4304606, -29.92, -51.18),
+  (4305108, -29.17, -51.18),
+  (4314407, -31.77, -52.34),
+  (4314902, -30.03, -51.23),
+  (5002704, -20.44, -54.65),
+  (5003702, -22.22, -54.81),
+  (5103403, -15.60, -56.10),
+  (5108402, -15.65, -56.13),
+  (5201108, -16.33, -48.95),
+  (5201405, -16.82, -49.25),
+  (5208707, -16.69, -49.26),
+  (5300108, -15.79, -47.88)
+) AS c(code, latitude, longitude)
+WHERE m.code = c.code;
+
+-- approximate location of the user, for now the center of their city
+ALTER TABLE users
+ADD COLUMN latitude DOUBLE PRECISION,
+ADD COLUMN longitude DOUBLE PRECISION;
+
+UPDATE users u
+SET latitude = m.latitude, longitude = m.longitude
+FROM ibge_municipalities m
+WHERE u.city_ibge_code = m.code;
+
+CREATE INDEX idx_users_latitude_longitude ON users(latitude, longitude);
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_users_description_search_vector;
+DROP INDEX IF EXISTS idx_users_name_search_vector;
+DROP INDEX IF EXISTS idx_posts_search_vector;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS description_search_vector,
+DROP COLUMN IF EXISTS name_search_vector;
+
+ALTER TABLE posts
+DROP COLUMN IF EXISTS search_vector;
+
+DROP TEXT SEARCH CONFIGURATION IF EXISTS portuguese_unaccent;
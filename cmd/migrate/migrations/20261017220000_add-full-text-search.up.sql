@@ -0,0 +1,23 @@
+-- portuguese stemming on top of unaccent, so "remédio" matches "remedios".
+-- Using a named configuration keeps to_tsvector immutable, which the
+-- generated columns below require.
+CREATE TEXT SEARCH CONFIGURATION portuguese_unaccent (COPY = portuguese);
+
+ALTER TEXT SEARCH CONFIGURATION portuguese_unaccent
+ALTER MAPPING FOR hword, hword_part, word
+WITH unaccent, portuguese_stem;
+
+ALTER TABLE posts
+ADD COLUMN search_vector TSVECTOR
+GENERATED ALWAYS AS (to_tsvector('portuguese_unaccent', COALESCE(description, ''))) STORED;
+
+-- the description is indexed apart from the name because users can hide it
+ALTER TABLE users
+ADD COLUMN name_search_vector TSVECTOR
+GENERATED ALWAYS AS (setweight(to_tsvector('portuguese_unaccent', name || ' ' || surname), 'A')) STORED,
+ADD COLUMN description_search_vector TSVECTOR
+GENERATED ALWAYS AS (setweight(to_tsvector('portuguese_unaccent', COALESCE(description, '')), 'B')) STORED;
+
+CREATE INDEX idx_posts_search_vector ON posts USING GIN (search_vector);
+CREATE INDEX idx_users_name_search_vector ON users USING GIN (name_search_vector);
+CREATE INDEX idx_users_description_search_vector ON users USING GIN (description_search_vector);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_users_city_id;
+DROP INDEX IF EXISTS idx_notifications_user_id_created_at_id;
+DROP INDEX IF EXISTS idx_comments_post_id_created_at_id;
+DROP INDEX IF EXISTS idx_posts_user_id_created_at_id;
+DROP INDEX IF EXISTS idx_posts_created_at_id;
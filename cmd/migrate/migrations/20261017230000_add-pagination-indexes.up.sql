@@ -0,0 +1,6 @@
+-- keyset pagination of the list endpoints
+CREATE INDEX IF NOT EXISTS idx_posts_created_at_id ON posts(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_posts_user_id_created_at_id ON posts(user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_comments_post_id_created_at_id ON comments(post_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id_created_at_id ON notifications(user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_users_city_id ON users(city, id);
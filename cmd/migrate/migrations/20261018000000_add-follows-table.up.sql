@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS follows (
+  follower_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  followee_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (follower_id, followee_id),
+  CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX idx_follows_followee_id ON follows(followee_id);
@@ -0,0 +1,5 @@
+ALTER TABLE users
+DROP COLUMN IF EXISTS referred_by;
+
+DROP TABLE IF EXISTS user_badges;
+DROP TABLE IF EXISTS points_ledger;
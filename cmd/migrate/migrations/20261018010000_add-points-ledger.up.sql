@@ -0,0 +1,29 @@
+-- users.points is the sum of the ledger, which can always be recomputed from
+-- it. reference_id is what the points were given for (a transaction, a
+-- referred user...) or 0, and makes awarding the same event twice a no-op.
+CREATE TABLE IF NOT EXISTS points_ledger (
+  id SERIAL PRIMARY KEY,
+  user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  event VARCHAR(50) NOT NULL,
+  reference_id INT NOT NULL DEFAULT 0,
+  points INT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (user_id, event, reference_id)
+);
+
+CREATE INDEX idx_points_ledger_user_id_created_at_id ON points_ledger(user_id, created_at DESC, id DESC);
+
+CREATE TABLE IF NOT EXISTS user_badges (
+  id SERIAL PRIMARY KEY,
+  user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  badge VARCHAR(50) NOT NULL,
+  unlocked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (user_id, badge)
+);
+
+ALTER TABLE users
+ADD COLUMN referred_by INT REFERENCES users(id) ON DELETE SET NULL;
+
+-- keep whatever points users already have as the opening balance
+INSERT INTO points_ledger (user_id, event, points)
+SELECT id, 'adjustment', points FROM users WHERE points <> 0;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS donor_stats;
+
+ALTER TABLE privacy_settings
+DROP COLUMN IF EXISTS show_on_leaderboard;
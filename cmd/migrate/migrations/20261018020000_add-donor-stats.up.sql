@@ -0,0 +1,42 @@
+ALTER TABLE privacy_settings
+ADD COLUMN show_on_leaderboard BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- Running totals per donor and period, kept up to date as donations are
+-- confirmed and points are awarded, so the leaderboards never scan
+-- transactions. period is 'week', 'month' or 'all', period_start is the first
+-- day of the week or month ('1970-01-01' for 'all').
+CREATE TABLE IF NOT EXISTS donor_stats (
+  user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  period VARCHAR(10) NOT NULL,
+  period_start DATE NOT NULL,
+  amount NUMERIC(12, 2) NOT NULL DEFAULT 0,
+  donations INT NOT NULL DEFAULT 0,
+  points INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (user_id, period, period_start)
+);
+
+CREATE INDEX idx_donor_stats_period_amount ON donor_stats(period, period_start, amount DESC);
+CREATE INDEX idx_donor_stats_period_points ON donor_stats(period, period_start, points DESC);
+
+-- same as leaderboard.Store.Rebuild
+INSERT INTO donor_stats (user_id, period, period_start, amount, donations, points)
+SELECT user_id, period, period_start, SUM(amount), SUM(donations), SUM(points)
+FROM (
+  SELECT t.payer_id AS user_id, p.period, p.period_start, t.amount, 1 AS donations, 0 AS points
+  FROM transactions t
+  CROSS JOIN LATERAL (VALUES
+    ('week', date_trunc('week', t.updated_at)::date),
+    ('month', date_trunc('month', t.updated_at)::date),
+    ('all', DATE '1970-01-01')
+  ) AS p(period, period_start)
+  WHERE t.status = '1'
+  UNION ALL
+  SELECT l.user_id, p.period, p.period_start, 0, 0, l.points
+  FROM points_ledger l
+  CROSS JOIN LATERAL (VALUES
+    ('week', date_trunc('week', l.created_at)::date),
+    ('month', date_trunc('month', l.created_at)::date),
+    ('all', DATE '1970-01-01')
+  ) AS p(period, period_start)
+) events
+GROUP BY user_id, period, period_start;
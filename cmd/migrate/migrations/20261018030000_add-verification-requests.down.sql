@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS verification_requests;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS is_verified;
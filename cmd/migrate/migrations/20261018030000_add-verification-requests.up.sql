@@ -0,0 +1,22 @@
+ALTER TABLE users
+ADD COLUMN is_verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Payee verification (KYC) submissions. The documents live in the storage
+-- bucket, only their keys are kept here.
+CREATE TABLE IF NOT EXISTS verification_requests (
+  id SERIAL PRIMARY KEY,
+  user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  status VARCHAR(20) NOT NULL DEFAULT 'pending',
+  id_document_path VARCHAR(255) NOT NULL,
+  address_proof_path VARCHAR(255) NOT NULL,
+  rejection_reason TEXT,
+  reviewed_by INT REFERENCES users(id) ON DELETE SET NULL,
+  reviewed_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- a payee can only have one submission waiting for review
+CREATE UNIQUE INDEX idx_verification_requests_pending ON verification_requests(user_id) WHERE status = 'pending';
+CREATE INDEX idx_verification_requests_status_created_at ON verification_requests(status, created_at, id);
+CREATE INDEX idx_verification_requests_user_id ON verification_requests(user_id, created_at DESC);
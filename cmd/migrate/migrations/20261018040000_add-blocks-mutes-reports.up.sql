@@ -0,0 +1,34 @@
+-- A block hides the blocked user from the blocker and stops them from
+-- commenting on the blocker's posts, following or donating to them. A mute
+-- only hides the muted user's posts and notifications.
+CREATE TABLE IF NOT EXISTS user_blocks (
+  blocker_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  blocked_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (blocker_id, blocked_id),
+  CHECK (blocker_id <> blocked_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_mutes (
+  muter_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  muted_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (muter_id, muted_id),
+  CHECK (muter_id <> muted_id)
+);
+
+CREATE TABLE IF NOT EXISTS reports (
+  id SERIAL PRIMARY KEY,
+  reporter_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  reported_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  reason VARCHAR(20) NOT NULL,
+  details TEXT,
+  status VARCHAR(20) NOT NULL DEFAULT 'open',
+  resolved_by INT REFERENCES users(id) ON DELETE SET NULL,
+  resolved_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CHECK (reporter_id <> reported_id)
+);
+
+CREATE INDEX idx_reports_status_created_at ON reports(status, created_at, id);
+CREATE INDEX idx_reports_reported_id ON reports(reported_id);
@@ -0,0 +1,4 @@
+ALTER TABLE transactions DROP COLUMN IF EXISTS campaign_id;
+
+DROP TABLE IF EXISTS campaign_photos;
+DROP TABLE IF EXISTS campaigns;
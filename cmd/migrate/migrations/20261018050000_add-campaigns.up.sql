@@ -0,0 +1,33 @@
+-- Fundraising campaigns of payees. raised_amount and donations are kept up
+-- to date as the donations targeting the campaign are confirmed.
+CREATE TABLE IF NOT EXISTS campaigns (
+  id SERIAL PRIMARY KEY,
+  user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  title VARCHAR(120) NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  goal_amount NUMERIC(12, 2) NOT NULL CHECK (goal_amount > 0),
+  raised_amount NUMERIC(12, 2) NOT NULL DEFAULT 0,
+  donations INT NOT NULL DEFAULT 0,
+  status VARCHAR(20) NOT NULL DEFAULT 'open',
+  deadline TIMESTAMP NOT NULL,
+  closed_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_campaigns_user_id ON campaigns(user_id, created_at DESC);
+CREATE INDEX idx_campaigns_open_deadline ON campaigns(deadline, id) WHERE status = 'open';
+
+CREATE TABLE IF NOT EXISTS campaign_photos (
+  id SERIAL PRIMARY KEY,
+  campaign_id INT NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+  filename VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_campaign_photos_campaign_id ON campaign_photos(campaign_id);
+
+ALTER TABLE transactions
+ADD COLUMN campaign_id INT REFERENCES campaigns(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_transactions_campaign_id ON transactions(campaign_id) WHERE campaign_id IS NOT NULL;
@@ -0,0 +1,5 @@
+-- The post that led to a donation, if any.
+ALTER TABLE transactions
+ADD COLUMN post_id INT REFERENCES posts(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_transactions_post_id ON transactions(post_id) WHERE post_id IS NOT NULL;
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS comment_revisions;
+DROP TABLE IF EXISTS post_revisions;
+
+DROP INDEX IF EXISTS idx_post_photos_post_id;
+CREATE INDEX idx_post_photos_post_id ON post_photos(post_id);
+
+ALTER TABLE post_photos DROP COLUMN IF EXISTS position;
+ALTER TABLE comments DROP COLUMN IF EXISTS edited_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS edited_at;
@@ -0,0 +1,42 @@
+-- edited_at marks posts and comments changed after they were published
+ALTER TABLE posts
+ADD COLUMN edited_at TIMESTAMP;
+
+ALTER TABLE comments
+ADD COLUMN edited_at TIMESTAMP;
+
+-- photos used to be shown in upload order, which stays the starting order
+ALTER TABLE post_photos
+ADD COLUMN position INT NOT NULL DEFAULT 0;
+
+UPDATE post_photos ph
+SET position = ordered.position
+FROM (
+  SELECT id, ROW_NUMBER() OVER (PARTITION BY post_id ORDER BY id) - 1 AS position
+  FROM post_photos
+) ordered
+WHERE ph.id = ordered.id;
+
+DROP INDEX IF EXISTS idx_post_photos_post_id;
+CREATE INDEX idx_post_photos_post_id ON post_photos(post_id, position, id);
+
+-- Each edit keeps the version it replaced. Removed photos stay in the
+-- storage while a revision lists them and are deleted with the post.
+CREATE TABLE IF NOT EXISTS post_revisions (
+  id SERIAL PRIMARY KEY,
+  post_id INT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+  description TEXT NOT NULL,
+  photos TEXT[] NOT NULL DEFAULT '{}',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_post_revisions_post_id ON post_revisions(post_id, created_at DESC, id DESC);
+
+CREATE TABLE IF NOT EXISTS comment_revisions (
+  id SERIAL PRIMARY KEY,
+  comment_id INT NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+  content TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_comment_revisions_comment_id ON comment_revisions(comment_id, created_at DESC, id DESC);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_notifications_user_id_updated_at_id;
+DROP INDEX IF EXISTS idx_notifications_unread_post;
+
+ALTER TABLE notifications
+DROP COLUMN IF EXISTS event_count;
+
+DROP TABLE IF EXISTS reactions;
@@ -0,0 +1,25 @@
+-- A user has at most one reaction to each post and each comment, reacting
+-- again replaces it.
+CREATE TABLE IF NOT EXISTS reactions (
+  id SERIAL PRIMARY KEY,
+  user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  post_id INT REFERENCES posts(id) ON DELETE CASCADE,
+  comment_id INT REFERENCES comments(id) ON DELETE CASCADE,
+  kind VARCHAR(20) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CHECK (num_nonnulls(post_id, comment_id) = 1)
+);
+
+CREATE UNIQUE INDEX idx_reactions_post_id_user_id ON reactions(post_id, user_id) WHERE post_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_reactions_comment_id_user_id ON reactions(comment_id, user_id) WHERE comment_id IS NOT NULL;
+
+-- Reactions to a post and to the comments of the author on it are batched
+-- into a single unread notification, which counts how many came in. A
+-- batched notification keeps its created_at and moves up through updated_at,
+-- which is what the notifications are listed by.
+ALTER TABLE notifications
+ADD COLUMN event_count INT NOT NULL DEFAULT 1;
+
+CREATE UNIQUE INDEX idx_notifications_unread_post ON notifications(user_id, resource_id) WHERE type = 'post' AND NOT is_read;
+CREATE INDEX idx_notifications_user_id_updated_at_id ON notifications(user_id, updated_at DESC, id DESC);
@@ -0,0 +1,4 @@
+DELETE FROM tokens WHERE type = 'email_change';
+
+ALTER TABLE tokens
+DROP COLUMN IF EXISTS email;
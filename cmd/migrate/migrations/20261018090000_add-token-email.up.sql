@@ -0,0 +1,3 @@
+-- email change tokens keep the address being confirmed
+ALTER TABLE tokens
+ADD COLUMN email VARCHAR(100);
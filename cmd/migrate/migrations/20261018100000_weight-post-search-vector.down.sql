@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_posts_search_vector;
+
+ALTER TABLE posts
+DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE posts
+ADD COLUMN search_vector TSVECTOR
+GENERATED ALWAYS AS (to_tsvector('portuguese_unaccent', COALESCE(description, ''))) STORED;
+
+CREATE INDEX idx_posts_search_vector ON posts USING GIN (search_vector);
@@ -0,0 +1,12 @@
+-- post descriptions rank like user descriptions in the combined search,
+-- which the default D weight left well below every user match
+DROP INDEX IF EXISTS idx_posts_search_vector;
+
+ALTER TABLE posts
+DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE posts
+ADD COLUMN search_vector TSVECTOR
+GENERATED ALWAYS AS (setweight(to_tsvector('portuguese_unaccent', COALESCE(description, '')), 'B')) STORED;
+
+CREATE INDEX idx_posts_search_vector ON posts USING GIN (search_vector);
@@ -0,0 +1,10 @@
+-- following again after an unfollow used to notify again, keep the latest
+-- unread notification of each follower
+DELETE FROM notifications n
+USING notifications newer
+WHERE n.type = 'follow' AND NOT n.is_read
+  AND newer.type = 'follow' AND NOT newer.is_read
+  AND newer.user_id = n.user_id AND newer.resource_id = n.resource_id
+  AND newer.id > n.id;
+
+CREATE UNIQUE INDEX idx_notifications_unread_follow ON notifications(user_id, resource_id) WHERE type = 'follow' AND NOT is_read;
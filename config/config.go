@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/alissoncorsair/appsolidario-backend/types"
 	"github.com/joho/godotenv"
@@ -21,27 +22,40 @@ func initConfig() *types.Config {
 	}
 
 	return &types.Config{
-		Host:                          getEnv("POSTGRES_HOST", "localhost"),
-		Port:                          port,
-		PostgresPassword:              getEnv("POSTGRES_PASSWORD", "postgres"),
-		PostgresUser:                  getEnv("POSTGRES_USER", "postgres"),
-		PostgresDB:                    getEnv("POSTGRES_DB", "postgres"),
-		SSLMode:                       getEnv("POSTGRES_SSL_MODE", "disable"),
-		JWTExpirationInSeconds:        getEnvAsInt64("JWT_EXPIRATION_IN_SECONDS", 30),
-		JWTSecret:                     getEnv("JWT_SECRET", "secret"),
-		JWTRefreshExpirationInSeconds: getEnvAsInt64("JWT_REFRESH_EXPIRATION_IN_SECONDS", 60),
-		SendgridApiKey:                getEnv("SENDGRID_API_KEY", ""),
-		EmailFrom:                     getEnv("EMAIL_FROM", ""),
-		EmailFromName:                 getEnv("EMAIL_FROM_NAME", ""),
-		EmailVerifyUrl:                getEnv("EMAIL_VERIFY_URL", ""),
-		R2AccountID:                   getEnv("R2_ACCOUNT_ID", ""),
-		R2BucketName:                  getEnv("R2_BUCKET_NAME", ""),
-		R2AccessKeyID:                 getEnv("R2_ACCESS_KEY_ID", ""),
-		R2AccessKeySecret:             getEnv("R2_ACCESS_KEY_SECRET", ""),
-		DevMode:                       getEnvAsBool("DEV_MODE", false),
-		PGCert:                        getEnv("POSTGRES_SSL_CERT", ""),
-		MercadoPagoAccessToken:        getEnv("MERCADO_PAGO_ACCESS_TOKEN", ""),
-		MercadoPagoWebhookSecret:      getEnv("MERCADO_PAGO_WEBHOOK_SECRET", ""),
+		Host:                             getEnv("POSTGRES_HOST", "localhost"),
+		Port:                             port,
+		PostgresPassword:                 getEnv("POSTGRES_PASSWORD", "postgres"),
+		PostgresUser:                     getEnv("POSTGRES_USER", "postgres"),
+		PostgresDB:                       getEnv("POSTGRES_DB", "postgres"),
+		SSLMode:                          getEnv("POSTGRES_SSL_MODE", "disable"),
+		JWTExpirationInSeconds:           getEnvAsInt64("JWT_EXPIRATION_IN_SECONDS", 30),
+		JWTKeysDir:                       getEnv("JWT_KEYS_DIR", "keys"),
+		JWTSigningKeyID:                  getEnv("JWT_SIGNING_KEY_ID", ""),
+		JWTRefreshExpirationInSeconds:    getEnvAsInt64("JWT_REFRESH_EXPIRATION_IN_SECONDS", 60),
+		SendgridApiKey:                   getEnv("SENDGRID_API_KEY", ""),
+		EmailFrom:                        getEnv("EMAIL_FROM", ""),
+		EmailFromName:                    getEnv("EMAIL_FROM_NAME", ""),
+		EmailVerifyUrl:                   getEnv("EMAIL_VERIFY_URL", ""),
+		EmailResetPasswordUrl:            getEnv("EMAIL_RESET_PASSWORD_URL", ""),
+		PasswordResetExpirationInSeconds: getEnvAsInt64("PASSWORD_RESET_EXPIRATION_IN_SECONDS", 3600),
+		EmailUnlockAccountUrl:            getEnv("EMAIL_UNLOCK_ACCOUNT_URL", ""),
+		LoginMaxAttemptsPerCPF:           getEnvAsInt64("LOGIN_MAX_ATTEMPTS_PER_CPF", 10),
+		LoginMaxAttemptsPerIP:            getEnvAsInt64("LOGIN_MAX_ATTEMPTS_PER_IP", 50),
+		LoginBackoffAfterAttempts:        getEnvAsInt64("LOGIN_BACKOFF_AFTER_ATTEMPTS", 3),
+		LoginBackoffBaseSeconds:          getEnvAsInt64("LOGIN_BACKOFF_BASE_SECONDS", 2),
+		LoginLockoutDurationInSeconds:    getEnvAsInt64("LOGIN_LOCKOUT_DURATION_IN_SECONDS", 900),
+		AccountDeletionGraceInSeconds:    getEnvAsInt64("ACCOUNT_DELETION_GRACE_IN_SECONDS", 30*24*60*60),
+		R2AccountID:                      getEnv("R2_ACCOUNT_ID", ""),
+		R2BucketName:                     getEnv("R2_BUCKET_NAME", ""),
+		R2AccessKeyID:                    getEnv("R2_ACCESS_KEY_ID", ""),
+		R2AccessKeySecret:                getEnv("R2_ACCESS_KEY_SECRET", ""),
+		DevMode:                          getEnvAsBool("DEV_MODE", false),
+		PGCert:                           getEnv("POSTGRES_SSL_CERT", ""),
+		MercadoPagoAccessToken:           getEnv("MERCADO_PAGO_ACCESS_TOKEN", ""),
+		MercadoPagoWebhookSecret:         getEnv("MERCADO_PAGO_WEBHOOK_SECRET", ""),
+		UnverifiedPayeeMaxDonation:       getEnvAsInt64("UNVERIFIED_PAYEE_MAX_DONATION", 200),
+		UnverifiedPayeeMonthlyLimit:      getEnvAsInt64("UNVERIFIED_PAYEE_MONTHLY_LIMIT", 1000),
+		TrustedProxies:                   getEnvAsList("TRUSTED_PROXIES"),
 	}
 }
 
@@ -65,6 +79,18 @@ func getEnvAsInt64(key string, fallback int64) int64 {
 	return fallback
 }
 
+// getEnvAsList splits a comma separated value, dropping empty entries.
+func getEnvAsList(key string) []string {
+	var list []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+
+	return list
+}
+
 func getEnvAsBool(key string, fallback bool) bool {
 	if value, ok := os.LookupEnv(key); ok {
 		return value == "true"
@@ -0,0 +1,59 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+)
+
+const earthRadiusKm = 6371.0
+
+// DistanceKm is the great-circle (haversine) distance between two points.
+// The same formula is used in SQL by the nearby queries.
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := radians(lat2 - lat1)
+	dLon := radians(lon2 - lon1)
+
+	a := math.Pow(math.Sin(dLat/2), 2) +
+		math.Cos(radians(lat1))*math.Cos(radians(lat2))*math.Pow(math.Sin(dLon/2), 2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Box is a latitude/longitude rectangle around a point.
+type Box struct {
+	MinLat, MaxLat float64
+	MinLon, MaxLon float64
+}
+
+// BoundingBox returns a rectangle that contains every point within radiusKm
+// of the origin. It is used to filter rows with the index before computing
+// the exact distance.
+func BoundingBox(lat, lon, radiusKm float64) Box {
+	dLat := radiusKm / earthRadiusKm * 180 / math.Pi
+
+	// longitude degrees shrink towards the poles; Brazil never gets close
+	// to them, but keep the division safe anyway
+	cosLat := math.Max(math.Cos(radians(lat)), 0.01)
+	dLon := dLat / cosLat
+
+	return Box{
+		MinLat: lat - dLat,
+		MaxLat: lat + dLat,
+		MinLon: lon - dLon,
+		MaxLon: lon + dLon,
+	}
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// DistanceSQL is the Postgres version of DistanceKm, between the given
+// columns and parameter placeholders. LEAST guards ASIN against rounding
+// errors for points that are (almost) the same.
+func DistanceSQL(latColumn, lonColumn, latParam, lonParam string) string {
+	return fmt.Sprintf(
+		"(2 * %[5]g * ASIN(LEAST(1, SQRT(POWER(SIN(RADIANS(%[1]s - %[3]s) / 2), 2) + COS(RADIANS(%[3]s)) * COS(RADIANS(%[1]s)) * POWER(SIN(RADIANS(%[2]s - %[4]s) / 2), 2)))))",
+		latColumn, lonColumn, latParam, lonParam, earthRadiusKm,
+	)
+}
@@ -0,0 +1,373 @@
+// Package geo normalizes Brazilian addresses against the IBGE state and
+// municipality tables embedded in data/.
+//
+// The CSV files follow the IBGE layout (7 digit municipality code, 2 digit
+// state code) and are the source of the ibge_states and ibge_municipalities
+// tables, which Seed loads on every "migrate up". The municipality file is
+// regenerated with the full IBGE list by cmd/ibge, and Seed refuses a file
+// with fewer than MinMunicipalities rows so a truncated list can't reach the
+// database. Cities missing from it are kept as typed, without an IBGE code
+// and without coordinates.
+package geo
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/paemuri/brdoc"
+)
+
+//go:embed data/states.csv
+var statesCSV []byte
+
+//go:embed data/municipalities.csv
+var municipalitiesCSV []byte
+
+//go:embed data/cep_regions.csv
+var cepRegionsCSV []byte
+
+// MinMunicipalities is a floor below the ~5570 municipalities IBGE lists,
+// leaving room for the few that are created or merged between censuses.
+const MinMunicipalities = 5500
+
+var (
+	ErrUnknownState    = errors.New("estado inválido")
+	ErrInvalidCEP      = errors.New("cep inválido")
+	ErrCEPDoesNotMatch = errors.New("o cep não pertence ao estado informado")
+)
+
+type State struct {
+	Code int
+	UF   string
+	Name string
+}
+
+// Municipality coordinates are the approximate center of the city.
+type Municipality struct {
+	Code      int
+	Name      string
+	State     *State
+	Latitude  float64
+	Longitude float64
+}
+
+// Address is a normalized city and state. CityIBGECode is nil for cities
+// that are not in the dataset, and so are the coordinates unless the CEP
+// falls in a known CEP region.
+type Address struct {
+	City         string
+	State        string
+	CityIBGECode *int
+	Latitude     *float64
+	Longitude    *float64
+}
+
+var (
+	statesByUF     = map[string]*State{}
+	statesByName   = map[string]*State{}
+	statesByCode   = map[int]*State{}
+	municipalities = map[string]*Municipality{}
+	citiesByName   = map[string][]*Municipality{}
+	cepRegions     = map[string][2]float64{}
+)
+
+// cepUnits maps our UFs to brdoc's, which knows the CEP ranges of each state.
+var cepUnits = map[string]brdoc.FederativeUnit{
+	"AC": brdoc.AC, "AL": brdoc.AL, "AP": brdoc.AP, "AM": brdoc.AM, "BA": brdoc.BA,
+	"CE": brdoc.CE, "DF": brdoc.DF, "ES": brdoc.ES, "GO": brdoc.GO, "MA": brdoc.MA,
+	"MT": brdoc.MT, "MS": brdoc.MS, "MG": brdoc.MG, "PA": brdoc.PA, "PB": brdoc.PB,
+	"PR": brdoc.PR, "PE": brdoc.PE, "PI": brdoc.PI, "RJ": brdoc.RJ, "RN": brdoc.RN,
+	"RS": brdoc.RS, "RO": brdoc.RO, "RR": brdoc.RR, "SC": brdoc.SC, "SP": brdoc.SP,
+	"SE": brdoc.SE, "TO": brdoc.TO,
+}
+
+func init() {
+	if err := load(); err != nil {
+		log.Fatalf("failed to load IBGE data: %v", err)
+	}
+}
+
+func load() error {
+	states, err := readCSV(statesCSV)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range states {
+		code, err := strconv.Atoi(record[0])
+		if err != nil {
+			return fmt.Errorf("invalid state code %q: %w", record[0], err)
+		}
+
+		state := &State{Code: code, UF: record[1], Name: record[2]}
+		statesByCode[code] = state
+		statesByUF[state.UF] = state
+		statesByName[Fold(state.Name)] = state
+	}
+
+	cities, err := readCSV(municipalitiesCSV)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range cities {
+		code, err := strconv.Atoi(record[0])
+		if err != nil {
+			return fmt.Errorf("invalid municipality code %q: %w", record[0], err)
+		}
+
+		stateCode, err := strconv.Atoi(record[2])
+		if err != nil {
+			return fmt.Errorf("invalid state code %q: %w", record[2], err)
+		}
+
+		state, ok := statesByCode[stateCode]
+		if !ok {
+			return fmt.Errorf("unknown state %d for municipality %d", stateCode, code)
+		}
+
+		latitude, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			return fmt.Errorf("invalid latitude %q for municipality %d: %w", record[3], code, err)
+		}
+
+		longitude, err := strconv.ParseFloat(record[4], 64)
+		if err != nil {
+			return fmt.Errorf("invalid longitude %q for municipality %d: %w", record[4], code, err)
+		}
+
+		municipality := &Municipality{
+			Code:      code,
+			Name:      record[1],
+			State:     state,
+			Latitude:  latitude,
+			Longitude: longitude,
+		}
+		municipalities[cityKey(municipality.Name, state.UF)] = municipality
+		citiesByName[Fold(municipality.Name)] = append(citiesByName[Fold(municipality.Name)], municipality)
+	}
+
+	regions, err := readCSV(cepRegionsCSV)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range regions {
+		prefix := record[0]
+
+		if !singleState(prefix) {
+			return fmt.Errorf("CEP region %q does not belong to a single state", prefix)
+		}
+
+		latitude, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			return fmt.Errorf("invalid latitude %q for CEP region %s: %w", record[1], prefix, err)
+		}
+
+		longitude, err := strconv.ParseFloat(record[2], 64)
+		if err != nil {
+			return fmt.Errorf("invalid longitude %q for CEP region %s: %w", record[2], prefix, err)
+		}
+
+		cepRegions[prefix] = [2]float64{latitude, longitude}
+	}
+
+	return nil
+}
+
+// singleState reports whether every CEP starting with prefix belongs to the
+// same state, so the fallback can't place a user outside the state the CEP
+// was checked against. States are told apart by the first 3 digits.
+func singleState(prefix string) bool {
+	subprefixes := []string{prefix}
+	if len(prefix) == 2 {
+		subprefixes = nil
+		for digit := '0'; digit <= '9'; digit++ {
+			subprefixes = append(subprefixes, prefix+string(digit))
+		}
+	}
+
+	var state *State
+	for _, subprefix := range subprefixes {
+		current, ok := StateFromCEP(subprefix + "00000")
+		if !ok || (state != nil && current != state) {
+			return false
+		}
+
+		state = current
+	}
+
+	return true
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+
+	// skip the header
+	if _, err := reader.Read(); err != nil {
+		return nil, err
+	}
+
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+}
+
+func cityKey(name string, uf string) string {
+	return uf + "|" + Fold(name)
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// Fold lowercases the text, removes accents and collapses spaces, so
+// "  São  Paulo" and "sao paulo" compare equal.
+func Fold(s string) string {
+	s = accents.Replace(strings.ToLower(s))
+	s = strings.ReplaceAll(s, "-", " ")
+	s = strings.ReplaceAll(s, "'", " ")
+
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// FindState accepts either the UF ("SP") or the state name, in any case and
+// with or without accents.
+func FindState(s string) (*State, bool) {
+	s = strings.TrimSpace(s)
+
+	if state, ok := statesByUF[strings.ToUpper(s)]; ok {
+		return state, true
+	}
+
+	state, ok := statesByName[Fold(s)]
+	return state, ok
+}
+
+func FindMunicipality(name string, uf string) (*Municipality, bool) {
+	m, ok := municipalities[cityKey(name, uf)]
+	return m, ok
+}
+
+// StateFromCEP returns the state a CEP belongs to.
+func StateFromCEP(cep string) (*State, bool) {
+	if !brdoc.ValidateCEPFormat(cep) {
+		return nil, false
+	}
+
+	for uf, unit := range cepUnits {
+		if brdoc.IsCEP(cep, unit) {
+			return statesByUF[uf], true
+		}
+	}
+
+	return nil, false
+}
+
+// CEPCentroid returns the approximate center of the CEP region of cep, from
+// data/cep_regions.csv, matching the longest known prefix (3 digits, then 2).
+// Regions are placed at their main city, which is good enough to find users
+// of a neighbouring town that isn't in the municipality table.
+func CEPCentroid(cep string) (float64, float64, bool) {
+	for _, size := range []int{3, 2} {
+		if len(cep) < size {
+			continue
+		}
+
+		if center, ok := cepRegions[cep[:size]]; ok {
+			return center[0], center[1], true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// CanonicalCityName returns the IBGE spelling of a city typed by a user, as
+// long as only one municipality has that name. Otherwise the name is
+// returned trimmed.
+func CanonicalCityName(name string) string {
+	matches := citiesByName[Fold(name)]
+
+	if len(matches) == 1 {
+		return matches[0].Name
+	}
+
+	return strings.TrimSpace(name)
+}
+
+// Normalize resolves the state (falling back to the one of the CEP when
+// empty), checks that the CEP belongs to it and matches the city against the
+// IBGE table. Cities missing from the table are located by their CEP region.
+func Normalize(city string, state string, cep string) (*Address, error) {
+	var resolved *State
+
+	if strings.TrimSpace(state) != "" {
+		var ok bool
+		resolved, ok = FindState(state)
+
+		if !ok {
+			return nil, ErrUnknownState
+		}
+	}
+
+	if cep != "" {
+		cepState, ok := StateFromCEP(cep)
+
+		if !ok {
+			return nil, ErrInvalidCEP
+		}
+
+		if resolved == nil {
+			resolved = cepState
+		}
+
+		if resolved != cepState {
+			return nil, ErrCEPDoesNotMatch
+		}
+	}
+
+	if resolved == nil {
+		return nil, ErrUnknownState
+	}
+
+	address := &Address{
+		City:  strings.Join(strings.Fields(city), " "),
+		State: resolved.UF,
+	}
+
+	if municipality, ok := FindMunicipality(city, resolved.UF); ok {
+		code := municipality.Code
+		latitude, longitude := municipality.Latitude, municipality.Longitude
+		address.City = municipality.Name
+		address.CityIBGECode = &code
+		address.Latitude = &latitude
+		address.Longitude = &longitude
+	} else if latitude, longitude, ok := CEPCentroid(cep); ok {
+		address.Latitude = &latitude
+		address.Longitude = &longitude
+	}
+
+	return address, nil
+}
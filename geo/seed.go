@@ -0,0 +1,155 @@
+package geo
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Seed loads the embedded states and municipalities into the ibge_states
+// and ibge_municipalities tables, updating the rows that changed, and then
+// matches the users that have no IBGE code yet with Fold, the same way new
+// addresses are matched. Users of a known city get its coordinates, and the
+// others the center of their CEP region.
+func Seed(db *sql.DB) error {
+	if len(municipalities) < MinMunicipalities {
+		return fmt.Errorf("data/municipalities.csv has %d municipalities, expected at least %d: run make ibge-data", len(municipalities), MinMunicipalities)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, state := range statesByCode {
+		query := `
+		INSERT INTO ibge_states (code, uf, name) VALUES ($1, $2, $3)
+		ON CONFLICT (code) DO UPDATE SET uf = EXCLUDED.uf, name = EXCLUDED.name
+		`
+		if _, err := tx.Exec(query, state.Code, state.UF, state.Name); err != nil {
+			return fmt.Errorf("error seeding state %s: %w", state.UF, err)
+		}
+	}
+
+	var codes, stateCodes []int64
+	var names []string
+	var latitudes, longitudes []float64
+	for _, m := range municipalities {
+		codes = append(codes, int64(m.Code))
+		names = append(names, m.Name)
+		stateCodes = append(stateCodes, int64(m.State.Code))
+		latitudes = append(latitudes, m.Latitude)
+		longitudes = append(longitudes, m.Longitude)
+	}
+
+	query := `
+	INSERT INTO ibge_municipalities (code, name, state_code, latitude, longitude)
+	SELECT * FROM unnest($1::int[], $2::varchar[], $3::smallint[], $4::float8[], $5::float8[])
+	ON CONFLICT (code) DO UPDATE
+	SET name = EXCLUDED.name, state_code = EXCLUDED.state_code, latitude = EXCLUDED.latitude, longitude = EXCLUDED.longitude
+	`
+	_, err = tx.Exec(query, pq.Array(codes), pq.Array(names), pq.Array(stateCodes), pq.Array(latitudes), pq.Array(longitudes))
+	if err != nil {
+		return fmt.Errorf("error seeding municipalities: %w", err)
+	}
+
+	if err := matchUsers(tx); err != nil {
+		return err
+	}
+
+	query = `
+	UPDATE users u
+	SET latitude = m.latitude, longitude = m.longitude
+	FROM ibge_municipalities m
+	WHERE u.city_ibge_code = m.code AND u.latitude IS NULL
+	`
+	if _, err := tx.Exec(query); err != nil {
+		return fmt.Errorf("error setting user coordinates: %w", err)
+	}
+
+	if err := locateUsers(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+func matchUsers(tx *sql.Tx) error {
+	rows, err := tx.Query("SELECT id, city, state FROM users WHERE city_ibge_code IS NULL")
+	if err != nil {
+		return fmt.Errorf("error getting users without IBGE code: %w", err)
+	}
+	defer rows.Close()
+
+	matched := map[int]*Municipality{}
+	for rows.Next() {
+		var id int
+		var city, state string
+		if err := rows.Scan(&id, &city, &state); err != nil {
+			return fmt.Errorf("error scanning user: %w", err)
+		}
+
+		if resolved, ok := FindState(state); ok {
+			if m, ok := FindMunicipality(city, resolved.UF); ok {
+				matched[id] = m
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows.Close()
+
+	for id, m := range matched {
+		query := `UPDATE users SET city = $1, state = $2, city_ibge_code = $3 WHERE id = $4`
+		if _, err := tx.Exec(query, m.Name, m.State.UF, m.Code, id); err != nil {
+			return fmt.Errorf("error matching user %d: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+func locateUsers(tx *sql.Tx) error {
+	rows, err := tx.Query("SELECT id, postal_code FROM users WHERE latitude IS NULL AND postal_code IS NOT NULL")
+	if err != nil {
+		return fmt.Errorf("error getting users without coordinates: %w", err)
+	}
+	defer rows.Close()
+
+	located := map[int][2]float64{}
+	for rows.Next() {
+		var id int
+		var postalCode string
+		if err := rows.Scan(&id, &postalCode); err != nil {
+			return fmt.Errorf("error scanning user: %w", err)
+		}
+
+		if latitude, longitude, ok := CEPCentroid(postalCode); ok {
+			located[id] = [2]float64{latitude, longitude}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows.Close()
+
+	for id, center := range located {
+		query := `UPDATE users SET latitude = $1, longitude = $2 WHERE id = $3`
+		if _, err := tx.Exec(query, center[0], center[1], id); err != nil {
+			return fmt.Errorf("error locating user %d: %w", id, err)
+		}
+	}
+
+	return nil
+}
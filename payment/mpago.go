@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/alissoncorsair/appsolidario-backend/types"
 	"github.com/alissoncorsair/appsolidario-backend/utils"
@@ -13,6 +14,9 @@ import (
 
 var url = "https://api.mercadopago.com/v1/payments"
 
+// PixExpiration is how long a generated Pix can be paid for.
+const PixExpiration = 24 * time.Hour
+
 type MercadoPago struct {
 	AccessToken string
 }
@@ -22,6 +26,11 @@ type PaymentInfo struct {
 	Description    string  `json:"description"`
 	ReceiverID     int     `json:"receiver_id"`
 	IdempotencyKey string  `json:"idempotency_key"`
+	// CampaignID makes the donation count towards one of the receiver's
+	// campaigns.
+	CampaignID *int `json:"campaign_id"`
+	// PostID is the post of the receiver the donation was made from.
+	PostID *int `json:"post_id"`
 }
 
 type Identification struct {
@@ -47,6 +56,7 @@ type GeneratePixPaymentRequest struct {
 	TransactionAmount float64 `json:"transaction_amount"`
 	Description       string  `json:"description"`
 	PaymentMethodId   string  `json:"payment_method_id"`
+	DateOfExpiration  string  `json:"date_of_expiration"`
 	Payer             Payer   `json:"payer"`
 }
 
@@ -149,6 +159,7 @@ func (mp *MercadoPago) GeneratePixPayment(paymentInfo PaymentInfo, user types.Us
 		TransactionAmount: paymentInfo.Amount,
 		Description:       paymentInfo.Description,
 		PaymentMethodId:   "pix",
+		DateOfExpiration:  time.Now().Add(PixExpiration).Format("2006-01-02T15:04:05.000-07:00"),
 		Payer: Payer{
 			Email:     user.Email,
 			FirstName: user.Name,
@@ -0,0 +1,112 @@
+package account_deletion
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/alissoncorsair/appsolidario-backend/config"
+	"github.com/alissoncorsair/appsolidario-backend/service/auth"
+	"github.com/alissoncorsair/appsolidario-backend/service/session"
+	"github.com/alissoncorsair/appsolidario-backend/service/user"
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+	"github.com/go-playground/validator/v10"
+)
+
+type Handler struct {
+	store        *Store
+	userStore    *user.Store
+	sessionStore *session.Store
+}
+
+func NewHandler(store *Store, userStore *user.Store, sessionStore *session.Store) *Handler {
+	return &Handler{
+		store:        store,
+		userStore:    userStore,
+		sessionStore: sessionStore,
+	}
+}
+
+func (h *Handler) HandleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	var payload types.DeleteAccountRequest
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	if !auth.ComparePassword(user.Password, []byte(payload.Password)) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("senha incorreta"))
+		return
+	}
+
+	grace := time.Duration(config.Envs.AccountDeletionGraceInSeconds) * time.Second
+	deletion, err := h.store.ScheduleAccountDeletion(user.ID, time.Now().Add(grace))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to schedule account deletion: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusAccepted, deletion)
+}
+
+func (h *Handler) HandleGetAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	userID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	deletion, err := h.store.GetAccountDeletion(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if deletion == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("no account deletion scheduled"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, deletion)
+}
+
+func (h *Handler) HandleCancelAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	userID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	cancelled, err := h.store.CancelAccountDeletion(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !cancelled {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("no account deletion scheduled"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Exclusão da conta cancelada"})
+}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("DELETE /me", auth.WithJWTAuth(h.HandleDeleteAccount, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /me/deletion", auth.WithJWTAuth(h.HandleGetAccountDeletion, h.userStore, h.sessionStore))
+	router.HandleFunc("DELETE /me/deletion", auth.WithJWTAuth(h.HandleCancelAccountDeletion, h.userStore, h.sessionStore))
+}
@@ -0,0 +1,185 @@
+package account_deletion
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/alissoncorsair/appsolidario-backend/types"
+)
+
+// TombstoneCPF identifies the placeholder user that keeps the transactions
+// and notifications of deleted accounts. It is created by the migration.
+const TombstoneCPF = "00000000000"
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+func ScanRowIntoAccountDeletion(row *sql.Row) (*types.AccountDeletion, error) {
+	var d types.AccountDeletion
+	err := row.Scan(&d.UserID, &d.RequestedAt, &d.ScheduledFor)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+func (s *Store) GetAccountDeletion(userID int) (*types.AccountDeletion, error) {
+	query := `
+		SELECT user_id, requested_at, scheduled_for
+		FROM account_deletions
+		WHERE user_id = $1
+	`
+
+	return ScanRowIntoAccountDeletion(s.db.QueryRow(query, userID))
+}
+
+// ScheduleAccountDeletion schedules the deletion, keeping the original date
+// when it was already requested.
+func (s *Store) ScheduleAccountDeletion(userID int, scheduledFor time.Time) (*types.AccountDeletion, error) {
+	query := `
+		INSERT INTO account_deletions (user_id, scheduled_for)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+		RETURNING user_id, requested_at, scheduled_for
+	`
+
+	return ScanRowIntoAccountDeletion(s.db.QueryRow(query, userID, scheduledFor))
+}
+
+// CancelAccountDeletion reports whether there was a deletion to cancel.
+func (s *Store) CancelAccountDeletion(userID int) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM account_deletions WHERE user_id = $1", userID)
+	if err != nil {
+		return false, fmt.Errorf("error cancelling account deletion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetDueAccountDeletions returns the users whose grace period is over.
+func (s *Store) GetDueAccountDeletions() ([]int, error) {
+	rows, err := s.db.Query("SELECT user_id FROM account_deletions WHERE scheduled_for <= NOW() ORDER BY scheduled_for")
+	if err != nil {
+		return nil, fmt.Errorf("error getting account deletions: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("error scanning account deletion: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// DeleteUser removes the user and everything they published. Transactions
+// and notifications sent by them are kept for accounting but moved to the
+// tombstone user. It returns the storage files that belonged to the user so
+// they can be purged once the rows are gone.
+func (s *Store) DeleteUser(userID int) ([]string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var tombstoneID int
+	err = tx.QueryRow("SELECT id FROM users WHERE cpf = $1", TombstoneCPF).Scan(&tombstoneID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting tombstone user: %w", err)
+	}
+
+	if tombstoneID == userID {
+		return nil, fmt.Errorf("the tombstone user cannot be deleted")
+	}
+
+	filesQuery := `
+		SELECT ph.filename FROM post_photos ph JOIN posts p ON p.id = ph.post_id WHERE p.user_id = $1
+		UNION
+		SELECT unnest(pr.photos) FROM post_revisions pr JOIN posts p ON p.id = pr.post_id WHERE p.user_id = $1
+		UNION ALL
+		SELECT path FROM profile_pictures WHERE user_id = $1
+		UNION ALL
+		SELECT filename FROM data_exports WHERE user_id = $1 AND filename IS NOT NULL
+		UNION ALL
+		SELECT id_document_path FROM verification_requests WHERE user_id = $1
+		UNION ALL
+		SELECT address_proof_path FROM verification_requests WHERE user_id = $1
+		UNION ALL
+		SELECT cp.filename FROM campaign_photos cp JOIN campaigns c ON c.id = cp.campaign_id WHERE c.user_id = $1
+	`
+	rows, err := tx.Query(filesQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user files: %w", err)
+	}
+
+	var files []string
+	for rows.Next() {
+		var filename string
+		if err := rows.Scan(&filename); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning filename: %w", err)
+		}
+		files = append(files, filename)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{"UPDATE transactions SET payer_id = $1 WHERE payer_id = $2", []interface{}{tombstoneID, userID}},
+		{"UPDATE transactions SET payee_id = $1 WHERE payee_id = $2", []interface{}{tombstoneID, userID}},
+		{"DELETE FROM notifications WHERE type = $1 AND from_user_id = $2", []interface{}{types.TypeFollow, userID}},
+		{"UPDATE notifications SET from_user_id = $1 WHERE from_user_id = $2", []interface{}{tombstoneID, userID}},
+		{"DELETE FROM notifications WHERE user_id = $1", []interface{}{userID}},
+		{"DELETE FROM notifications WHERE type = $1 AND resource_id IN (SELECT id FROM posts WHERE user_id = $2)", []interface{}{types.TypePost, userID}},
+		{"DELETE FROM notifications WHERE type = $1 AND resource_id IN (SELECT id FROM campaigns WHERE user_id = $2)", []interface{}{types.TypeCampaignCompleted, userID}},
+		{"DELETE FROM comments WHERE user_id = $1 OR post_id IN (SELECT id FROM posts WHERE user_id = $1)", []interface{}{userID}},
+		{"DELETE FROM post_photos WHERE post_id IN (SELECT id FROM posts WHERE user_id = $1)", []interface{}{userID}},
+		{"DELETE FROM posts WHERE user_id = $1", []interface{}{userID}},
+		// profile pictures, tokens, sessions, 2FA, exports, follows,
+		// verification requests and campaigns cascade, and the donations
+		// lose their campaign
+		{"DELETE FROM users WHERE id = $1", []interface{}{userID}},
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement.query, statement.args...); err != nil {
+			return nil, fmt.Errorf("error deleting user %d: %w", userID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return files, nil
+}
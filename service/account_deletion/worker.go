@@ -0,0 +1,59 @@
+package account_deletion
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/alissoncorsair/appsolidario-backend/storage"
+)
+
+type Worker struct {
+	store   *Store
+	storage *storage.R2Storage
+}
+
+func NewWorker(store *Store, storage *storage.R2Storage) *Worker {
+	return &Worker{
+		store:   store,
+		storage: storage,
+	}
+}
+
+// Run deletes the accounts whose grace period is over, checking again every
+// interval. It never returns, so start it in its own goroutine.
+func (w *Worker) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.deleteDueAccounts()
+		<-ticker.C
+	}
+}
+
+func (w *Worker) deleteDueAccounts() {
+	userIDs, err := w.store.GetDueAccountDeletions()
+	if err != nil {
+		log.Printf("failed to get account deletions: %v", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		files, err := w.store.DeleteUser(userID)
+		if err != nil {
+			log.Printf("failed to delete user %d: %v", userID, err)
+			continue
+		}
+
+		// the rows are already gone, so a file that fails here is only
+		// logged and has to be removed by hand
+		for _, filename := range files {
+			if err := w.storage.DeleteFile(context.Background(), filename); err != nil {
+				log.Printf("failed to delete file %s of user %d: %v", filename, userID, err)
+			}
+		}
+
+		log.Printf("deleted user %d", userID)
+	}
+}
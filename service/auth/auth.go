@@ -14,89 +14,185 @@ import (
 	"github.com/alissoncorsair/appsolidario-backend/types"
 	"github.com/alissoncorsair/appsolidario-backend/utils"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type contextKey string
 
 const UserKey contextKey = "userID"
+const SessionKey contextKey = "sessionID"
+const CurrentUserKey contextKey = "user"
 
-func StoreToken(store token.Store, token *types.Token) error {
+func StoreToken(store *token.Store, token *types.Token) error {
 	_, err := store.CreateToken(token)
 	return err
 }
 
-func CreateJWT(secret []byte, userID int, tokenType types.TokenType, expiration time.Duration) (string, error) {
-	expirationTime := time.Now().Add(expiration).Unix()
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+func CreateJWT(userID int, tokenType types.TokenType, expiration time.Duration) (string, error) {
+	return signJWT(jwt.MapClaims{
 		"userID":    strconv.Itoa(userID),
-		"exp":       expirationTime,
+		"exp":       time.Now().Add(expiration).Unix(),
 		"tokenType": tokenType,
 	})
+}
 
-	tokenString, err := token.SignedString(secret)
+// CreateSessionJWT creates a token bound to a session, so revoking the
+// session also invalidates it.
+func CreateSessionJWT(userID int, sessionID int, tokenType types.TokenType, expiration time.Duration) (string, error) {
+	return signJWT(jwt.MapClaims{
+		"userID":    strconv.Itoa(userID),
+		"sessionID": strconv.Itoa(sessionID),
+		"exp":       time.Now().Add(expiration).Unix(),
+		"tokenType": tokenType,
+	})
+}
 
+func signJWT(claims jwt.MapClaims) (string, error) {
+	keyID, key, err := getSigningKey()
 	if err != nil {
 		return "", err
 	}
 
-	return tokenString, nil
-}
+	// makes every token unique, even two issued for the same user in the
+	// same second, so persisted refresh tokens can be told apart
+	claims["jti"] = uuid.New().String()
 
-func HandleTokenRefresh(w http.ResponseWriter, r *http.Request) {
-	var request struct {
-		RefreshToken string `json:"refresh_token"`
-	}
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = keyID
 
-	if err := utils.ParseJSON(r, &request); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request payload"))
-		return
-	}
+	tokenString, err := token.SignedString(key)
 
-	token, err := ValidateToken(request.RefreshToken, types.TokenTypeRefresh)
-	if err != nil || !token.Valid {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid refresh token"))
-		return
+	if err != nil {
+		return "", err
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid token claims"))
-		return
-	}
+	return tokenString, nil
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
 
-	userIDStr := claims["userID"].(string)
-	userID, err := strconv.Atoi(userIDStr)
+// IssueTokenPair creates a new access/refresh pair for the session and
+// persists the refresh token so it can later be rotated or revoked.
+func IssueTokenPair(store *token.Store, userID int, sessionID int) (*TokenPair, error) {
+	accessToken, err := CreateSessionJWT(userID, sessionID, types.TokenTypeAccess, time.Duration(config.Envs.JWTExpirationInSeconds)*time.Second)
 	if err != nil {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid user ID"))
-		return
+		return nil, fmt.Errorf("could not generate access token: %w", err)
 	}
 
-	// Generate new access token and refresh token
-	accessToken, err := CreateJWT([]byte(config.Envs.JWTSecret), userID, types.TokenTypeAccess, time.Duration(config.Envs.JWTExpirationInSeconds)*time.Second)
+	refreshExpiration := time.Duration(config.Envs.JWTRefreshExpirationInSeconds) * time.Second
+	refreshToken, err := CreateSessionJWT(userID, sessionID, types.TokenTypeRefresh, refreshExpiration)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("could not generate access token"))
-		return
+		return nil, fmt.Errorf("could not generate refresh token: %w", err)
 	}
 
-	refreshToken, err := CreateJWT([]byte(config.Envs.JWTSecret), userID, types.TokenTypeRefresh, time.Duration(config.Envs.JWTRefreshExpirationInSeconds)*time.Second)
+	err = StoreToken(store, &types.Token{
+		UserID:    userID,
+		Token:     token.Hash(refreshToken),
+		Type:      types.TokenTypeRefresh,
+		ExpiresAt: time.Now().Add(refreshExpiration),
+		SessionID: &sessionID,
+	})
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("could not generate refresh token"))
-		return
+		return nil, fmt.Errorf("could not store refresh token: %w", err)
 	}
 
-	response := struct {
-		AccessToken  string `json:"access_token"`
-		RefreshToken string `json:"refresh_token"`
-	}{
+	return &TokenPair{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
+	}, nil
+}
+
+// GetRefreshToken validates a refresh JWT and returns its persisted record.
+// It returns nil when the token is invalid, was never issued by us or does
+// not belong to a session.
+func GetRefreshToken(store *token.Store, refreshToken string) (*types.Token, error) {
+	validated, err := ValidateToken(refreshToken, types.TokenTypeRefresh)
+	if err != nil || !validated.Valid {
+		return nil, nil
+	}
+
+	stored, err := store.GetToken(token.Hash(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+
+	if stored == nil || stored.SessionID == nil {
+		return nil, nil
 	}
 
-	utils.WriteJSON(w, http.StatusOK, response)
+	return stored, nil
+}
+
+func HandleTokenRefresh(store *token.Store, sessionStore types.SessionStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request types.RefreshTokenRequest
+
+		if err := utils.ParseJSON(r, &request); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request payload"))
+			return
+		}
+
+		stored, err := GetRefreshToken(store, request.RefreshToken)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if stored == nil {
+			utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid refresh token"))
+			return
+		}
+
+		active, err := store.RevokeToken(stored.ID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if !active {
+			// an already rotated token is being used again, so either the
+			// client or an attacker holds a stolen copy: log the user out
+			// everywhere
+			log.Printf("refresh token reuse detected for user %d", stored.UserID)
+
+			if err := sessionStore.RevokeUserSessions(stored.UserID); err != nil {
+				log.Printf("failed to revoke sessions: %v", err)
+			}
+
+			utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid refresh token"))
+			return
+		}
+
+		sessionActive, err := sessionStore.IsSessionActive(*stored.SessionID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if !sessionActive {
+			utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("session revoked"))
+			return
+		}
+
+		if err := sessionStore.TouchSession(*stored.SessionID); err != nil {
+			log.Printf("failed to update session: %v", err)
+		}
+
+		pair, err := IssueTokenPair(store, stored.UserID, *stored.SessionID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, pair)
+	}
 }
 
-func WithJWTAuth(handlerFunc http.HandlerFunc, store types.UserStore) http.HandlerFunc {
+func WithJWTAuth(handlerFunc http.HandlerFunc, store types.UserStore, sessionStore types.SessionStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		tokenString := getTokenFromRequest(r)
 		//token is validating both access and refresh tokens, but it should only validate access tokens
@@ -125,6 +221,28 @@ func WithJWTAuth(handlerFunc http.HandlerFunc, store types.UserStore) http.Handl
 			return
 		}
 
+		sessionStr, _ := claims["sessionID"].(string)
+		sessionID, err := strconv.Atoi(sessionStr)
+
+		if err != nil {
+			log.Printf("failed to convert sessionID to int: %v", err)
+			permissionDenied(w)
+			return
+		}
+
+		active, err := sessionStore.IsSessionActive(sessionID)
+
+		if err != nil {
+			log.Printf("failed to check session: %v", err)
+			permissionDenied(w)
+			return
+		}
+
+		if !active {
+			permissionDenied(w)
+			return
+		}
+
 		u, err := store.GetUserByID(userID)
 
 		if err != nil {
@@ -140,6 +258,8 @@ func WithJWTAuth(handlerFunc http.HandlerFunc, store types.UserStore) http.Handl
 
 		ctx := r.Context()
 		ctx = context.WithValue(ctx, UserKey, u.ID)
+		ctx = context.WithValue(ctx, SessionKey, sessionID)
+		ctx = context.WithValue(ctx, CurrentUserKey, u)
 
 		r = r.WithContext(ctx)
 
@@ -147,6 +267,30 @@ func WithJWTAuth(handlerFunc http.HandlerFunc, store types.UserStore) http.Handl
 	}
 }
 
+// WithRole only lets users with one of the given roles through. It must be
+// wrapped by WithJWTAuth, which loads the user into the context:
+//
+//	auth.WithJWTAuth(auth.WithRole(handler, types.RoleAdmin), userStore, sessionStore)
+func WithRole(handlerFunc http.HandlerFunc, roles ...types.UserRole) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, ok := GetUserFromContext(r.Context())
+
+		if !ok {
+			permissionDenied(w)
+			return
+		}
+
+		for _, role := range roles {
+			if u.RoleID == role {
+				handlerFunc(w, r)
+				return
+			}
+		}
+
+		permissionDenied(w)
+	}
+}
+
 func getTokenFromRequest(r *http.Request) string {
 	tokenAuth := r.Header.Get("Authorization")
 	prefix := "Bearer "
@@ -158,10 +302,17 @@ func getTokenFromRequest(r *http.Request) string {
 
 func ValidateToken(tokenString string, expectedType types.TokenType) (*jwt.Token, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		if _, ok := token.Method.(*jwt.SigningMethodEd25519); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(config.Envs.JWTSecret), nil
+
+		keyID, _ := token.Header["kid"].(string)
+		key, ok := getVerificationKey(keyID)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key: %q", keyID)
+		}
+
+		return key, nil
 	})
 
 	if err != nil {
@@ -189,6 +340,24 @@ func GetUserIDFromContext(ctx context.Context) (int, bool) {
 	return userID, true
 }
 
+func GetUserFromContext(ctx context.Context) (*types.User, bool) {
+	u, ok := ctx.Value(CurrentUserKey).(*types.User)
+	if !ok || u == nil {
+		return nil, false
+	}
+
+	return u, true
+}
+
+func GetSessionIDFromContext(ctx context.Context) (int, bool) {
+	sessionID, ok := ctx.Value(SessionKey).(int)
+	if !ok {
+		return -1, false
+	}
+
+	return sessionID, true
+}
+
 func HashPassword(password string) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 
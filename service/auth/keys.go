@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+)
+
+// Tokens are signed with Ed25519 (EdDSA). Every key lives in its own PEM file
+// inside the keys directory and its file name, without the extension, is the
+// kid placed in the token header:
+//
+//	keys/20261017120000.pem -> private key, can sign and verify
+//	keys/20260401090000.pem -> public key, only verifies tokens issued
+//	                           before the rotation
+//
+// To rotate, add a new private key and point JWT_SIGNING_KEY_ID at it (or let
+// the newest private key be picked). Keep the old key around, converted to a
+// public key if you like, until the tokens it signed have expired.
+
+type signingKey struct {
+	id  string
+	key ed25519.PrivateKey
+}
+
+var (
+	currentKey       *signingKey
+	verificationKeys = map[string]ed25519.PublicKey{}
+)
+
+// InitKeys loads the keys from dir and selects the signing key. When
+// signingKeyID is empty the last private key in name order is used. In dev
+// mode a missing or empty directory falls back to a throwaway key, so tokens
+// don't survive a restart.
+func InitKeys(dir string, signingKeyID string, devMode bool) error {
+	privateKeys, publicKeys, err := loadKeys(dir)
+	if err != nil && !(devMode && os.IsNotExist(err)) {
+		return fmt.Errorf("error loading jwt keys: %w", err)
+	}
+
+	if len(privateKeys) == 0 && devMode {
+		log.Println("no jwt signing keys found, using an ephemeral key")
+
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return fmt.Errorf("error generating jwt key: %w", err)
+		}
+
+		privateKeys = map[string]ed25519.PrivateKey{"dev": key}
+		signingKeyID = "dev"
+	}
+
+	if signingKeyID == "" {
+		ids := make([]string, 0, len(privateKeys))
+		for id := range privateKeys {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		if len(ids) > 0 {
+			signingKeyID = ids[len(ids)-1]
+		}
+	}
+
+	key, ok := privateKeys[signingKeyID]
+	if !ok {
+		return fmt.Errorf("jwt signing key %q not found in %s", signingKeyID, dir)
+	}
+
+	for id, privateKey := range privateKeys {
+		publicKeys[id] = privateKey.Public().(ed25519.PublicKey)
+	}
+
+	currentKey = &signingKey{id: signingKeyID, key: key}
+	verificationKeys = publicKeys
+
+	return nil
+}
+
+func loadKeys(dir string) (map[string]ed25519.PrivateKey, map[string]ed25519.PublicKey, error) {
+	privateKeys := map[string]ed25519.PrivateKey{}
+	publicKeys := map[string]ed25519.PublicKey{}
+
+	if _, err := os.Stat(dir); err != nil {
+		return privateKeys, publicKeys, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, file := range files {
+		id := strings.TrimSuffix(filepath.Base(file), ".pem")
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading jwt key %s: %w", file, err)
+		}
+
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, nil, fmt.Errorf("invalid jwt key %s: no PEM data", file)
+		}
+
+		switch block.Type {
+		case "PRIVATE KEY":
+			parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid jwt key %s: %w", file, err)
+			}
+
+			key, ok := parsed.(ed25519.PrivateKey)
+			if !ok {
+				return nil, nil, fmt.Errorf("invalid jwt key %s: not an Ed25519 key", file)
+			}
+
+			privateKeys[id] = key
+		case "PUBLIC KEY":
+			parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid jwt key %s: %w", file, err)
+			}
+
+			key, ok := parsed.(ed25519.PublicKey)
+			if !ok {
+				return nil, nil, fmt.Errorf("invalid jwt key %s: not an Ed25519 key", file)
+			}
+
+			publicKeys[id] = key
+		default:
+			return nil, nil, fmt.Errorf("invalid jwt key %s: unexpected PEM type %q", file, block.Type)
+		}
+	}
+
+	return privateKeys, publicKeys, nil
+}
+
+func getSigningKey() (string, crypto.Signer, error) {
+	if currentKey == nil {
+		return "", nil, fmt.Errorf("jwt keys not initialized")
+	}
+
+	return currentKey.id, currentKey.key, nil
+}
+
+func getVerificationKey(id string) (ed25519.PublicKey, bool) {
+	key, ok := verificationKeys[id]
+	return key, ok
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// HandleJWKS publishes every verification key so other services can check
+// our tokens without sharing any secret.
+func HandleJWKS(w http.ResponseWriter, r *http.Request) {
+	ids := make([]string, 0, len(verificationKeys))
+	for id := range verificationKeys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	jwks := JWKS{Keys: []JWK{}}
+	for _, id := range ids {
+		jwks.Keys = append(jwks.Keys, JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(verificationKeys[id]),
+			Kid: id,
+			Alg: "EdDSA",
+			Use: "sig",
+		})
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	utils.WriteJSON(w, http.StatusOK, jwks)
+}
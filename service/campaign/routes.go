@@ -0,0 +1,229 @@
+package campaign
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alissoncorsair/appsolidario-backend/service/auth"
+	"github.com/alissoncorsair/appsolidario-backend/service/session"
+	"github.com/alissoncorsair/appsolidario-backend/service/user"
+	"github.com/alissoncorsair/appsolidario-backend/storage"
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+	"github.com/go-playground/validator/v10"
+)
+
+type Handler struct {
+	store        *Store
+	userStore    *user.Store
+	sessionStore *session.Store
+	storage      *storage.R2Storage
+}
+
+func NewHandler(store *Store, userStore *user.Store, sessionStore *session.Store, storage *storage.R2Storage) *Handler {
+	return &Handler{
+		store:        store,
+		userStore:    userStore,
+		sessionStore: sessionStore,
+		storage:      storage,
+	}
+}
+
+// HandleCreateCampaign opens a campaign for the payee. It takes a multipart
+// form with the title, description, goal_amount and deadline fields and any
+// number of "photos" files. A deadline given as a plain date runs until the
+// end of that day.
+func (h *Handler) HandleCreateCampaign(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	if u.RoleID != types.RolePayee {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("apenas beneficiários podem criar campanhas"))
+		return
+	}
+
+	if err := r.ParseMultipartForm(10 << 20); err != nil { // 10 MB
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("failed to parse form: %w", err))
+		return
+	}
+
+	payload := types.CreateCampaignRequest{
+		Title:       strings.TrimSpace(r.FormValue("title")),
+		Description: strings.TrimSpace(r.FormValue("description")),
+		Deadline:    strings.TrimSpace(r.FormValue("deadline")),
+	}
+
+	goalAmount, err := strconv.ParseFloat(r.FormValue("goal_amount"), 64)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid goal_amount"))
+		return
+	}
+	payload.GoalAmount = goalAmount
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	deadline, err := utils.ParseDate(payload.Deadline)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if len(payload.Deadline) == len(time.DateOnly) {
+		deadline = deadline.AddDate(0, 0, 1)
+	}
+
+	if !deadline.After(time.Now()) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("o prazo da campanha precisa estar no futuro"))
+		return
+	}
+
+	campaign := &types.Campaign{
+		UserID:      u.ID,
+		Title:       payload.Title,
+		Description: payload.Description,
+		GoalAmount:  payload.GoalAmount,
+		Deadline:    deadline,
+	}
+
+	for _, fileHeader := range r.MultipartForm.File["photos"] {
+		file, err := fileHeader.Open()
+		if err != nil {
+			h.deletePhotos(r, campaign.Photos)
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to open file: %w", err))
+			return
+		}
+
+		_, filename, err := h.storage.UploadFile(r.Context(), file, fileHeader.Filename)
+		file.Close()
+		if err != nil {
+			h.deletePhotos(r, campaign.Photos)
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to upload file: %w", err))
+			return
+		}
+
+		campaign.Photos = append(campaign.Photos, filename)
+	}
+
+	created, err := h.store.CreateCampaign(campaign)
+	if err != nil {
+		h.deletePhotos(r, campaign.Photos)
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, created)
+}
+
+func (h *Handler) deletePhotos(r *http.Request, filenames []string) {
+	for _, filename := range filenames {
+		if err := h.storage.DeleteFile(r.Context(), filename); err != nil {
+			log.Printf("failed to delete campaign photo %s: %v", filename, err)
+		}
+	}
+}
+
+func (h *Handler) HandleGetCampaign(w http.ResponseWriter, r *http.Request) {
+	viewerID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid campaign ID"))
+		return
+	}
+
+	campaign, err := h.store.GetCampaignByID(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if campaign == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("campaign not found"))
+		return
+	}
+
+	blocked, err := h.userStore.IsBlocked(viewerID, campaign.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if blocked {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("campaign not found"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, campaign)
+}
+
+func (h *Handler) HandleGetOpenCampaigns(w http.ResponseWriter, r *http.Request) {
+	viewerID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	page, err := utils.ParsePageRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	campaigns, err := h.store.GetOpenCampaigns(viewerID, page)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, campaigns)
+}
+
+func (h *Handler) HandleGetUserCampaigns(w http.ResponseWriter, r *http.Request) {
+	viewerID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	userID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user ID"))
+		return
+	}
+
+	page, err := utils.ParsePageRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	campaigns, err := h.store.GetCampaignsByUserID(viewerID, userID, page)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, campaigns)
+}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("POST /campaigns", auth.WithJWTAuth(h.HandleCreateCampaign, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /campaigns", auth.WithJWTAuth(h.HandleGetOpenCampaigns, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /campaigns/{id}", auth.WithJWTAuth(h.HandleGetCampaign, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /users/{id}/campaigns", auth.WithJWTAuth(h.HandleGetUserCampaigns, h.userStore, h.sessionStore))
+}
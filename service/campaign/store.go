@@ -0,0 +1,314 @@
+package campaign
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/alissoncorsair/appsolidario-backend/service/moderation"
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+const campaignColumns = `c.id, c.user_id, c.title, c.description, c.goal_amount::float8, c.raised_amount::float8, c.donations, c.status, c.deadline, c.closed_at, c.created_at, c.updated_at`
+
+func scanCampaign(scanner interface{ Scan(...any) error }) (*types.Campaign, error) {
+	var c types.Campaign
+	err := scanner.Scan(&c.ID, &c.UserID, &c.Title, &c.Description, &c.GoalAmount, &c.RaisedAmount, &c.Donations, &c.Status, &c.Deadline, &c.ClosedAt, &c.CreatedAt, &c.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error scanning campaign: %w", err)
+	}
+
+	c.Progress = c.RaisedAmount / c.GoalAmount
+	c.Photos = []string{}
+
+	return &c, nil
+}
+
+func (s *Store) CreateCampaign(campaign *types.Campaign) (*types.Campaign, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO campaigns AS c (user_id, title, description, goal_amount, deadline)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING ` + campaignColumns
+
+	created, err := scanCampaign(tx.QueryRow(query, campaign.UserID, campaign.Title, campaign.Description, campaign.GoalAmount, campaign.Deadline))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, filename := range campaign.Photos {
+		if _, err := tx.Exec("INSERT INTO campaign_photos (campaign_id, filename) VALUES ($1, $2)", created.ID, filename); err != nil {
+			return nil, fmt.Errorf("error adding photo: %w", err)
+		}
+
+		created.Photos = append(created.Photos, filename)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetCampaignByID returns nil when the campaign doesn't exist.
+func (s *Store) GetCampaignByID(id int) (*types.Campaign, error) {
+	query := `SELECT ` + campaignColumns + ` FROM campaigns c WHERE c.id = $1`
+
+	campaign, err := scanCampaign(s.db.QueryRow(query, id))
+	if err != nil || campaign == nil {
+		return nil, err
+	}
+
+	if err := s.loadPhotos(campaign); err != nil {
+		return nil, err
+	}
+
+	return campaign, nil
+}
+
+// GetOpenCampaigns lists the campaigns still raising money, the ones closest
+// to their deadline first, leaving out the payees the viewer blocked.
+func (s *Store) GetOpenCampaigns(viewerID int, page utils.PageRequest) (*utils.Page[*types.Campaign], error) {
+	query := `
+	SELECT ` + campaignColumns + `
+	FROM campaigns c
+	WHERE c.status = $1 AND c.deadline > NOW()
+	  AND ` + moderation.NotBlockedSQL("c.user_id", "$5") + `
+	  AND ($2::timestamp IS NULL OR (c.deadline, c.id) > ($2, $3))
+	ORDER BY c.deadline, c.id
+	LIMIT $4
+	`
+
+	return s.getCampaigns(query, func(c *types.Campaign) utils.Cursor {
+		return utils.Cursor{Time: &c.Deadline, ID: c.ID}
+	}, page, types.CampaignOpen, page.AfterTime(), page.AfterID(), page.Limit+1, viewerID)
+}
+
+// GetCampaignsByUserID lists all the campaigns of a payee, newest first. It
+// is empty when the viewer blocked the payee.
+func (s *Store) GetCampaignsByUserID(viewerID int, userID int, page utils.PageRequest) (*utils.Page[*types.Campaign], error) {
+	query := `
+	SELECT ` + campaignColumns + `
+	FROM campaigns c
+	WHERE c.user_id = $1
+	  AND ` + moderation.NotBlockedSQL("c.user_id", "$5") + `
+	  AND ($2::timestamp IS NULL OR (c.created_at, c.id) < ($2, $3))
+	ORDER BY c.created_at DESC, c.id DESC
+	LIMIT $4
+	`
+
+	return s.getCampaigns(query, func(c *types.Campaign) utils.Cursor {
+		return utils.Cursor{Time: &c.CreatedAt, ID: c.ID}
+	}, page, userID, page.AfterTime(), page.AfterID(), page.Limit+1, viewerID)
+}
+
+func (s *Store) getCampaigns(query string, cursor func(*types.Campaign) utils.Cursor, page utils.PageRequest, args ...any) (*utils.Page[*types.Campaign], error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	campaigns := []*types.Campaign{}
+	for rows.Next() {
+		campaign, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		campaigns = append(campaigns, campaign)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows.Close()
+
+	result := utils.NewPage(campaigns, page.Limit, cursor)
+	for _, campaign := range result.Items {
+		if err := s.loadPhotos(campaign); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (s *Store) loadPhotos(campaign *types.Campaign) error {
+	rows, err := s.db.Query("SELECT filename FROM campaign_photos WHERE campaign_id = $1 ORDER BY id", campaign.ID)
+	if err != nil {
+		return fmt.Errorf("error getting campaign photos: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var filename string
+		if err := rows.Scan(&filename); err != nil {
+			return fmt.Errorf("error scanning filename: %w", err)
+		}
+
+		campaign.Photos = append(campaign.Photos, filename)
+	}
+
+	return rows.Err()
+}
+
+// progressSQL computes, as t, the amount raised and the number of confirmed
+// donations of every campaign. statusParam is the query parameter holding
+// types.StatusDone.
+func progressSQL(statusParam string) string {
+	return `(
+		SELECT c.id, COALESCE(SUM(tr.amount), 0) AS raised_amount, COUNT(tr.id) AS donations
+		FROM campaigns c
+		LEFT JOIN transactions tr ON tr.campaign_id = c.id AND tr.status = ` + statusParam + `
+		GROUP BY c.id
+	) t`
+}
+
+// RecordDonation recomputes the campaign progress from its confirmed
+// donations and closes the campaign once the goal is reached. It returns the
+// campaign only when this call is the one that completed it. Donations
+// confirmed after the campaign closed still count towards the amount raised.
+func (s *Store) RecordDonation(campaignID int) (*types.Campaign, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	UPDATE campaigns c
+	SET raised_amount = t.raised_amount, donations = t.donations, updated_at = NOW()
+	FROM ` + progressSQL("$2") + `
+	WHERE c.id = $1 AND t.id = c.id
+	`
+	if _, err := tx.Exec(query, campaignID, types.StatusDone); err != nil {
+		return nil, fmt.Errorf("error recording campaign donation: %w", err)
+	}
+
+	query = `
+	UPDATE campaigns c
+	SET status = $1, closed_at = NOW(), updated_at = NOW()
+	WHERE c.id = $2 AND c.status = $3 AND c.raised_amount >= c.goal_amount
+	RETURNING ` + campaignColumns
+
+	completed, err := scanCampaign(tx.QueryRow(query, types.CampaignCompleted, campaignID, types.CampaignOpen))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return completed, nil
+}
+
+// SyncProgress recomputes the progress of every campaign from the confirmed
+// donations, in case it drifted from them, and closes the open campaigns
+// that reached their goal. It returns the campaigns it completed.
+func (s *Store) SyncProgress() ([]*types.Campaign, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	UPDATE campaigns c
+	SET raised_amount = t.raised_amount, donations = t.donations, updated_at = NOW()
+	FROM ` + progressSQL("$1") + `
+	WHERE t.id = c.id AND (c.raised_amount <> t.raised_amount OR c.donations <> t.donations)
+	`
+	if _, err := tx.Exec(query, types.StatusDone); err != nil {
+		return nil, fmt.Errorf("error syncing campaign progress: %w", err)
+	}
+
+	query = `
+	UPDATE campaigns c
+	SET status = $1, closed_at = NOW(), updated_at = NOW()
+	WHERE c.status = $2 AND c.raised_amount >= c.goal_amount
+	RETURNING ` + campaignColumns
+
+	rows, err := tx.Query(query, types.CampaignCompleted, types.CampaignOpen)
+	if err != nil {
+		return nil, fmt.Errorf("error completing campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	completed := []*types.Campaign{}
+	for rows.Next() {
+		campaign, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		completed = append(completed, campaign)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows.Close()
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return completed, nil
+}
+
+// GetDonorIDs lists the users with a confirmed donation to the campaign.
+func (s *Store) GetDonorIDs(campaignID int) ([]int, error) {
+	rows, err := s.db.Query("SELECT DISTINCT payer_id FROM transactions WHERE campaign_id = $1 AND status = $2", campaignID, types.StatusDone)
+	if err != nil {
+		return nil, fmt.Errorf("error getting campaign donors: %w", err)
+	}
+	defer rows.Close()
+
+	donorIDs := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning donor: %w", err)
+		}
+
+		donorIDs = append(donorIDs, id)
+	}
+
+	return donorIDs, rows.Err()
+}
+
+// ExpireDueCampaigns closes the open campaigns whose deadline has passed and
+// returns how many were closed.
+func (s *Store) ExpireDueCampaigns() (int64, error) {
+	result, err := s.db.Exec("UPDATE campaigns SET status = $1, closed_at = NOW(), updated_at = NOW() WHERE status = $2 AND deadline <= NOW()", types.CampaignExpired, types.CampaignOpen)
+	if err != nil {
+		return 0, fmt.Errorf("error expiring campaigns: %w", err)
+	}
+
+	return result.RowsAffected()
+}
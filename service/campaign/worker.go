@@ -0,0 +1,83 @@
+package campaign
+
+import (
+	"log"
+	"time"
+
+	"github.com/alissoncorsair/appsolidario-backend/service/notification"
+	"github.com/alissoncorsair/appsolidario-backend/types"
+)
+
+type Worker struct {
+	store             *Store
+	notificationStore *notification.Store
+}
+
+func NewWorker(store *Store, notificationStore *notification.Store) *Worker {
+	return &Worker{
+		store:             store,
+		notificationStore: notificationStore,
+	}
+}
+
+// Run brings the campaign progress back in line with the confirmed
+// donations, completing the campaigns that reached their goal, and then
+// closes the ones that reached their deadline without meeting it, checking
+// again every interval. It never returns, so start it in its own goroutine.
+func (w *Worker) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.syncProgress()
+		w.expireDueCampaigns()
+		<-ticker.C
+	}
+}
+
+func (w *Worker) syncProgress() {
+	completed, err := w.store.SyncProgress()
+	if err != nil {
+		log.Printf("failed to sync campaign progress: %v", err)
+		return
+	}
+
+	for _, campaign := range completed {
+		NotifyCompletion(w.store, w.notificationStore, campaign)
+	}
+}
+
+func (w *Worker) expireDueCampaigns() {
+	expired, err := w.store.ExpireDueCampaigns()
+	if err != nil {
+		log.Printf("failed to expire campaigns: %v", err)
+		return
+	}
+
+	if expired > 0 {
+		log.Printf("expired %d campaigns", expired)
+	}
+}
+
+// NotifyCompletion lets every donor of a campaign that reached its goal know.
+func NotifyCompletion(store *Store, notificationStore *notification.Store, completed *types.Campaign) {
+	donorIDs, err := store.GetDonorIDs(completed.ID)
+	if err != nil {
+		log.Printf("failed to get the donors of campaign %d: %v", completed.ID, err)
+		return
+	}
+
+	for _, donorID := range donorIDs {
+		_, err := notificationStore.CreateNotification(&types.Notification{
+			UserID:     donorID,
+			FromUserID: completed.UserID,
+			Type:       types.TypeCampaignCompleted,
+			ResourceID: completed.ID,
+			IsRead:     false,
+		})
+
+		if err != nil {
+			log.Printf("failed to notify donor %d of campaign %d: %v", donorID, completed.ID, err)
+		}
+	}
+}
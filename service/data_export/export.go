@@ -0,0 +1,137 @@
+package data_export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"time"
+
+	"github.com/alissoncorsair/appsolidario-backend/storage"
+	"github.com/alissoncorsair/appsolidario-backend/types"
+)
+
+// exportExpiration is how long a finished archive can be downloaded before
+// the user has to ask for a new one.
+const exportExpiration = 7 * 24 * time.Hour
+
+// buildTimeout bounds the whole export, including fetching every file from
+// storage.
+const buildTimeout = 30 * time.Minute
+
+type Exporter struct {
+	store   *Store
+	storage *storage.R2Storage
+}
+
+func NewExporter(store *Store, storage *storage.R2Storage) *Exporter {
+	return &Exporter{
+		store:   store,
+		storage: storage,
+	}
+}
+
+// Run builds the archive for the export and uploads it to storage. It is
+// meant to run in its own goroutine; failures are recorded on the export.
+func (e *Exporter) Run(export *types.DataExport) {
+	ctx, cancel := context.WithTimeout(context.Background(), buildTimeout)
+	defer cancel()
+
+	if err := e.store.UpdateDataExportStatus(export.ID, types.DataExportProcessing); err != nil {
+		log.Printf("failed to start data export %d: %v", export.ID, err)
+		return
+	}
+
+	filename, err := e.build(ctx, export.UserID)
+	if err != nil {
+		log.Printf("failed to build data export %d: %v", export.ID, err)
+
+		if err := e.store.FailDataExport(export.ID, "não foi possível gerar o arquivo"); err != nil {
+			log.Printf("failed to update data export %d: %v", export.ID, err)
+		}
+		return
+	}
+
+	if err := e.store.CompleteDataExport(export.ID, filename, time.Now().Add(exportExpiration)); err != nil {
+		log.Printf("failed to complete data export %d: %v", export.ID, err)
+	}
+}
+
+func (e *Exporter) build(ctx context.Context, userID int) (string, error) {
+	data, err := e.store.GetUserData(userID)
+	if err != nil {
+		return "", err
+	}
+
+	// the archive can get big with all the photos, so it is written to disk
+	// instead of being kept in memory
+	tmp, err := os.CreateTemp("", "data-export-*.zip")
+	if err != nil {
+		return "", fmt.Errorf("error creating temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	archive := zip.NewWriter(tmp)
+
+	dataFile, err := archive.Create("data.json")
+	if err != nil {
+		return "", err
+	}
+
+	encoder := json.NewEncoder(dataFile)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(data); err != nil {
+		return "", fmt.Errorf("error encoding user data: %w", err)
+	}
+
+	for _, picture := range data.ProfilePictures {
+		if err := e.addFile(ctx, archive, path.Join("profile_pictures", picture.Path), picture.Path); err != nil {
+			return "", err
+		}
+	}
+
+	for _, photo := range data.PostPhotos {
+		if err := e.addFile(ctx, archive, path.Join("post_photos", photo.Filename), photo.Filename); err != nil {
+			return "", err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return "", fmt.Errorf("error writing archive: %w", err)
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	filename, err := e.storage.UploadPrivateFile(ctx, tmp, fmt.Sprintf("export-%d.zip", userID))
+	if err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
+
+func (e *Exporter) addFile(ctx context.Context, archive *zip.Writer, name string, filename string) error {
+	file, err := e.storage.GetFile(ctx, filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(writer, file); err != nil {
+		return fmt.Errorf("error copying %s: %w", filename, err)
+	}
+
+	return nil
+}
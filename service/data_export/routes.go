@@ -0,0 +1,155 @@
+package data_export
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alissoncorsair/appsolidario-backend/service/auth"
+	"github.com/alissoncorsair/appsolidario-backend/service/session"
+	"github.com/alissoncorsair/appsolidario-backend/service/user"
+	"github.com/alissoncorsair/appsolidario-backend/storage"
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+)
+
+// staleAfter is how long an export can stay pending or processing before we
+// assume the server died while building it and allow a new one.
+const staleAfter = time.Hour
+
+type Handler struct {
+	store        *Store
+	exporter     *Exporter
+	userStore    *user.Store
+	sessionStore *session.Store
+	storage      *storage.R2Storage
+}
+
+func NewHandler(store *Store, exporter *Exporter, userStore *user.Store, sessionStore *session.Store, storage *storage.R2Storage) *Handler {
+	return &Handler{
+		store:        store,
+		exporter:     exporter,
+		userStore:    userStore,
+		sessionStore: sessionStore,
+		storage:      storage,
+	}
+}
+
+func (h *Handler) HandleRequestExport(w http.ResponseWriter, r *http.Request) {
+	userID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	latest, err := h.store.GetLatestDataExport(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	inProgress := latest != nil &&
+		(latest.Status == types.DataExportPending || latest.Status == types.DataExportProcessing) &&
+		time.Since(latest.CreatedAt) < staleAfter
+
+	if inProgress {
+		utils.WriteJSON(w, http.StatusAccepted, latest)
+		return
+	}
+
+	export, err := h.store.CreateDataExport(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to create data export: %w", err))
+		return
+	}
+
+	// the previous archive is superseded by the new one
+	if latest != nil && latest.Filename != "" {
+		if err := h.storage.DeleteFile(r.Context(), latest.Filename); err != nil {
+			log.Printf("failed to delete old data export: %v", err)
+		}
+	}
+
+	go h.exporter.Run(export)
+
+	utils.WriteJSON(w, http.StatusAccepted, export)
+}
+
+func (h *Handler) HandleGetExport(w http.ResponseWriter, r *http.Request) {
+	export, ok := h.getExport(w, r)
+	if !ok {
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, export)
+}
+
+func (h *Handler) HandleDownloadExport(w http.ResponseWriter, r *http.Request) {
+	export, ok := h.getExport(w, r)
+	if !ok {
+		return
+	}
+
+	if export.Status != types.DataExportReady {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("export is not ready"))
+		return
+	}
+
+	if export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt) {
+		utils.WriteError(w, http.StatusGone, fmt.Errorf("export expired, request a new one"))
+		return
+	}
+
+	file, err := h.storage.GetFile(r.Context(), export.Filename)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"meus-dados-%d.zip\"", export.ID))
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, file); err != nil {
+		log.Printf("failed to send data export %d: %v", export.ID, err)
+	}
+}
+
+// getExport loads the export from the path, making sure it belongs to the
+// current user. It writes the error response and returns false otherwise.
+func (h *Handler) getExport(w http.ResponseWriter, r *http.Request) (*types.DataExport, bool) {
+	userID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return nil, false
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid export id"))
+		return nil, false
+	}
+
+	export, err := h.store.GetDataExport(id, userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	if export == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("export not found"))
+		return nil, false
+	}
+
+	return export, true
+}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("POST /me/export", auth.WithJWTAuth(h.HandleRequestExport, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /me/export/{id}", auth.WithJWTAuth(h.HandleGetExport, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /me/export/{id}/download", auth.WithJWTAuth(h.HandleDownloadExport, h.userStore, h.sessionStore))
+}
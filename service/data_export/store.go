@@ -0,0 +1,483 @@
+package data_export
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/lib/pq"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+func ScanRowIntoDataExport(row *sql.Row) (*types.DataExport, error) {
+	var e types.DataExport
+	var filename, exportError sql.NullString
+	err := row.Scan(&e.ID, &e.UserID, &e.Status, &filename, &exportError, &e.CreatedAt, &e.CompletedAt, &e.ExpiresAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	e.Filename = filename.String
+	e.Error = exportError.String
+
+	return &e, nil
+}
+
+func (s *Store) CreateDataExport(userID int) (*types.DataExport, error) {
+	query := `
+		INSERT INTO data_exports (user_id, status)
+		VALUES ($1, $2)
+		RETURNING id, user_id, status, filename, error, created_at, completed_at, expires_at
+	`
+
+	return ScanRowIntoDataExport(s.db.QueryRow(query, userID, types.DataExportPending))
+}
+
+func (s *Store) GetDataExport(id int, userID int) (*types.DataExport, error) {
+	query := `
+		SELECT id, user_id, status, filename, error, created_at, completed_at, expires_at
+		FROM data_exports
+		WHERE id = $1 AND user_id = $2
+	`
+
+	return ScanRowIntoDataExport(s.db.QueryRow(query, id, userID))
+}
+
+func (s *Store) GetLatestDataExport(userID int) (*types.DataExport, error) {
+	query := `
+		SELECT id, user_id, status, filename, error, created_at, completed_at, expires_at
+		FROM data_exports
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	return ScanRowIntoDataExport(s.db.QueryRow(query, userID))
+}
+
+func (s *Store) UpdateDataExportStatus(id int, status types.DataExportStatus) error {
+	_, err := s.db.Exec("UPDATE data_exports SET status = $1 WHERE id = $2", status, id)
+
+	if err != nil {
+		return fmt.Errorf("error updating data export: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) CompleteDataExport(id int, filename string, expiresAt time.Time) error {
+	query := `
+		UPDATE data_exports
+		SET status = $1, filename = $2, completed_at = NOW(), expires_at = $3
+		WHERE id = $4
+	`
+	_, err := s.db.Exec(query, types.DataExportReady, filename, expiresAt, id)
+
+	if err != nil {
+		return fmt.Errorf("error completing data export: %w", err)
+	}
+
+	return nil
+}
+
+// GetExpiredDataExports returns the ready exports past their expiration
+// whose archive is still in the storage.
+func (s *Store) GetExpiredDataExports() ([]*types.DataExport, error) {
+	query := `
+		SELECT id, user_id, filename
+		FROM data_exports
+		WHERE status = $1 AND expires_at < NOW() AND filename IS NOT NULL
+	`
+	rows, err := s.db.Query(query, types.DataExportReady)
+	if err != nil {
+		return nil, fmt.Errorf("error getting expired data exports: %w", err)
+	}
+	defer rows.Close()
+
+	exports := []*types.DataExport{}
+	for rows.Next() {
+		var e types.DataExport
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Filename); err != nil {
+			return nil, fmt.Errorf("error scanning data export: %w", err)
+		}
+		exports = append(exports, &e)
+	}
+
+	return exports, rows.Err()
+}
+
+// ClearDataExportFile forgets the archive of an export once it was deleted
+// from the storage. The export stays ready, so downloads keep answering that
+// it expired.
+func (s *Store) ClearDataExportFile(id int) error {
+	_, err := s.db.Exec("UPDATE data_exports SET filename = NULL WHERE id = $1", id)
+
+	if err != nil {
+		return fmt.Errorf("error clearing data export file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) FailDataExport(id int, reason string) error {
+	query := `
+		UPDATE data_exports
+		SET status = $1, error = $2, completed_at = NOW()
+		WHERE id = $3
+	`
+	_, err := s.db.Exec(query, types.DataExportFailed, reason, id)
+
+	if err != nil {
+		return fmt.Errorf("error failing data export: %w", err)
+	}
+
+	return nil
+}
+
+// UserData is everything the platform holds about a user, as written to
+// data.json inside the export archive.
+type UserData struct {
+	ExportedAt           time.Time                    `json:"exported_at"`
+	User                 types.UserWithoutPassword    `json:"user"`
+	ProfilePictures      []*types.ProfilePicture      `json:"profile_pictures"`
+	Posts                []*types.Post                `json:"posts"`
+	PostPhotos           []*types.PostPhoto           `json:"post_photos"`
+	Comments             []*types.Comment             `json:"comments"`
+	Notifications        []*types.Notification        `json:"notifications"`
+	Follows              []*types.Follow              `json:"follows"`
+	PointsLedger         []*types.PointsLedgerEntry   `json:"points_ledger"`
+	VerificationRequests []*types.VerificationRequest `json:"verification_requests"`
+	Campaigns            []*types.Campaign            `json:"campaigns"`
+	TransactionsAsPayer  []*types.Transaction         `json:"transactions_as_payer"`
+	TransactionsAsPayee  []*types.Transaction         `json:"transactions_as_payee"`
+}
+
+func (s *Store) GetUserData(userID int) (*UserData, error) {
+	data := &UserData{ExportedAt: time.Now()}
+	var err error
+
+	if err := s.getUser(userID, &data.User); err != nil {
+		return nil, err
+	}
+
+	if data.ProfilePictures, err = s.getProfilePictures(userID); err != nil {
+		return nil, err
+	}
+
+	if data.Posts, err = s.getPosts(userID); err != nil {
+		return nil, err
+	}
+
+	if data.PostPhotos, err = s.getPostPhotos(userID); err != nil {
+		return nil, err
+	}
+
+	if data.Comments, err = s.getComments(userID); err != nil {
+		return nil, err
+	}
+
+	if data.Notifications, err = s.getNotifications(userID); err != nil {
+		return nil, err
+	}
+
+	if data.Follows, err = s.getFollows(userID); err != nil {
+		return nil, err
+	}
+
+	if data.PointsLedger, err = s.getPointsLedger(userID); err != nil {
+		return nil, err
+	}
+
+	if data.VerificationRequests, err = s.getVerificationRequests(userID); err != nil {
+		return nil, err
+	}
+
+	if data.Campaigns, err = s.getCampaigns(userID); err != nil {
+		return nil, err
+	}
+
+	if data.TransactionsAsPayer, err = s.getTransactions("payer_id", userID); err != nil {
+		return nil, err
+	}
+
+	if data.TransactionsAsPayee, err = s.getTransactions("payee_id", userID); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (s *Store) getUser(userID int, u *types.UserWithoutPassword) error {
+	query := `
+		SELECT id, name, surname, email, status, description, postal_code, city, state, cpf, role_id, points, birth_date, created_at, updated_at, is_verified
+		FROM users
+		WHERE id = $1
+	`
+	err := s.db.QueryRow(query, userID).Scan(
+		&u.ID, &u.Name, &u.Surname, &u.Email, &u.Status, &u.Description, &u.PostalCode,
+		&u.City, &u.State, &u.CPF, &u.RoleID, &u.Points, &u.BirthDate, &u.CreatedAt, &u.UpdatedAt, &u.IsVerified,
+	)
+
+	if err != nil {
+		return fmt.Errorf("error getting user: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) getProfilePictures(userID int) ([]*types.ProfilePicture, error) {
+	rows, err := s.db.Query("SELECT id, user_id, path, created_at, updated_at FROM profile_pictures WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting profile pictures: %w", err)
+	}
+	defer rows.Close()
+
+	pictures := []*types.ProfilePicture{}
+	for rows.Next() {
+		var pp types.ProfilePicture
+		if err := rows.Scan(&pp.ID, &pp.UserID, &pp.Path, &pp.CreatedAt, &pp.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning profile picture: %w", err)
+		}
+		pictures = append(pictures, &pp)
+	}
+
+	return pictures, rows.Err()
+}
+
+func (s *Store) getPosts(userID int) ([]*types.Post, error) {
+	rows, err := s.db.Query("SELECT id, user_id, author_name, description, created_at, updated_at, edited_at FROM posts WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting posts: %w", err)
+	}
+	defer rows.Close()
+
+	posts := []*types.Post{}
+	for rows.Next() {
+		var post types.Post
+		if err := rows.Scan(&post.ID, &post.UserID, &post.AuthorName, &post.Description, &post.CreatedAt, &post.UpdatedAt, &post.EditedAt); err != nil {
+			return nil, fmt.Errorf("error scanning post: %w", err)
+		}
+		post.Comments = []*types.Comment{}
+		post.Photos = []string{}
+		posts = append(posts, &post)
+	}
+
+	return posts, rows.Err()
+}
+
+func (s *Store) getPostPhotos(userID int) ([]*types.PostPhoto, error) {
+	query := `
+		SELECT ph.id, ph.post_id, ph.filename, ph.position, ph.created_at
+		FROM post_photos ph
+		JOIN posts p ON p.id = ph.post_id
+		WHERE p.user_id = $1
+		ORDER BY ph.id
+	`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting post photos: %w", err)
+	}
+	defer rows.Close()
+
+	photos := []*types.PostPhoto{}
+	for rows.Next() {
+		var photo types.PostPhoto
+		if err := rows.Scan(&photo.ID, &photo.PostID, &photo.Filename, &photo.Position, &photo.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning post photo: %w", err)
+		}
+		photos = append(photos, &photo)
+	}
+
+	return photos, rows.Err()
+}
+
+func (s *Store) getComments(userID int) ([]*types.Comment, error) {
+	rows, err := s.db.Query("SELECT id, post_id, user_id, author_name, content, created_at, updated_at, edited_at FROM comments WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting comments: %w", err)
+	}
+	defer rows.Close()
+
+	comments := []*types.Comment{}
+	for rows.Next() {
+		var comment types.Comment
+		if err := rows.Scan(&comment.ID, &comment.PostID, &comment.UserID, &comment.AuthorName, &comment.Content, &comment.CreatedAt, &comment.UpdatedAt, &comment.EditedAt); err != nil {
+			return nil, fmt.Errorf("error scanning comment: %w", err)
+		}
+		comments = append(comments, &comment)
+	}
+
+	return comments, rows.Err()
+}
+
+func (s *Store) getNotifications(userID int) ([]*types.Notification, error) {
+	query := `
+		SELECT id, user_id, from_user_id, type, resource_id, is_read, created_at, updated_at, event_count
+		FROM notifications
+		WHERE user_id = $1
+		ORDER BY id
+	`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []*types.Notification{}
+	for rows.Next() {
+		var n types.Notification
+		var fromUserID sql.NullInt64
+		if err := rows.Scan(&n.ID, &n.UserID, &fromUserID, &n.Type, &n.ResourceID, &n.IsRead, &n.CreatedAt, &n.UpdatedAt, &n.Count); err != nil {
+			return nil, fmt.Errorf("error scanning notification: %w", err)
+		}
+		n.FromUserID = int(fromUserID.Int64)
+		notifications = append(notifications, &n)
+	}
+
+	return notifications, rows.Err()
+}
+
+// getFollows lists both who the user follows and who follows them.
+func (s *Store) getFollows(userID int) ([]*types.Follow, error) {
+	query := `
+		SELECT follower_id, followee_id, created_at
+		FROM follows
+		WHERE follower_id = $1 OR followee_id = $1
+		ORDER BY created_at
+	`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting follows: %w", err)
+	}
+	defer rows.Close()
+
+	follows := []*types.Follow{}
+	for rows.Next() {
+		var f types.Follow
+		if err := rows.Scan(&f.FollowerID, &f.FolloweeID, &f.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning follow: %w", err)
+		}
+		follows = append(follows, &f)
+	}
+
+	return follows, rows.Err()
+}
+
+func (s *Store) getPointsLedger(userID int) ([]*types.PointsLedgerEntry, error) {
+	query := `
+		SELECT id, user_id, event, reference_id, points, created_at
+		FROM points_ledger
+		WHERE user_id = $1
+		ORDER BY id
+	`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting points ledger: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*types.PointsLedgerEntry{}
+	for rows.Next() {
+		var e types.PointsLedgerEntry
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Event, &e.ReferenceID, &e.Points, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning points ledger entry: %w", err)
+		}
+		entries = append(entries, &e)
+	}
+
+	return entries, rows.Err()
+}
+
+func (s *Store) getVerificationRequests(userID int) ([]*types.VerificationRequest, error) {
+	query := `
+		SELECT id, user_id, status, rejection_reason, reviewed_at, created_at, updated_at
+		FROM verification_requests
+		WHERE user_id = $1
+		ORDER BY id
+	`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting verification requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []*types.VerificationRequest{}
+	for rows.Next() {
+		var vr types.VerificationRequest
+		if err := rows.Scan(&vr.ID, &vr.UserID, &vr.Status, &vr.RejectionReason, &vr.ReviewedAt, &vr.CreatedAt, &vr.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning verification request: %w", err)
+		}
+		requests = append(requests, &vr)
+	}
+
+	return requests, rows.Err()
+}
+
+func (s *Store) getCampaigns(userID int) ([]*types.Campaign, error) {
+	query := `
+		SELECT id, user_id, title, description, goal_amount::float8, raised_amount::float8, donations, status, deadline, closed_at, created_at, updated_at,
+		       ARRAY(SELECT filename FROM campaign_photos WHERE campaign_id = campaigns.id ORDER BY id)
+		FROM campaigns
+		WHERE user_id = $1
+		ORDER BY id
+	`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	campaigns := []*types.Campaign{}
+	for rows.Next() {
+		var c types.Campaign
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Title, &c.Description, &c.GoalAmount, &c.RaisedAmount, &c.Donations, &c.Status, &c.Deadline, &c.ClosedAt, &c.CreatedAt, &c.UpdatedAt, pq.Array(&c.Photos)); err != nil {
+			return nil, fmt.Errorf("error scanning campaign: %w", err)
+		}
+		c.Progress = c.RaisedAmount / c.GoalAmount
+		campaigns = append(campaigns, &c)
+	}
+
+	return campaigns, rows.Err()
+}
+
+// getTransactions lists the user's transactions on one side of the payment,
+// column being either payer_id or payee_id.
+func (s *Store) getTransactions(column string, userID int) ([]*types.Transaction, error) {
+	query := fmt.Sprintf(`
+		SELECT id, external_id, payer_id, payee_id, amount, status, description, created_at, updated_at, campaign_id, post_id
+		FROM transactions
+		WHERE %s = $1
+		ORDER BY id
+	`, column)
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting transactions: %w", err)
+	}
+	defer rows.Close()
+
+	transactions := []*types.Transaction{}
+	for rows.Next() {
+		var t types.Transaction
+		if err := rows.Scan(&t.ID, &t.ExternalID, &t.PayerID, &t.PayeeID, &t.Amount, &t.Status, &t.Description, &t.CreatedAt, &t.UpdatedAt, &t.CampaignID, &t.PostID); err != nil {
+			return nil, fmt.Errorf("error scanning transaction: %w", err)
+		}
+		transactions = append(transactions, &t)
+	}
+
+	return transactions, rows.Err()
+}
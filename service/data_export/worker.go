@@ -0,0 +1,52 @@
+package data_export
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/alissoncorsair/appsolidario-backend/storage"
+)
+
+type Worker struct {
+	store   *Store
+	storage *storage.R2Storage
+}
+
+func NewWorker(store *Store, storage *storage.R2Storage) *Worker {
+	return &Worker{
+		store:   store,
+		storage: storage,
+	}
+}
+
+// Run deletes the archives of expired exports from the storage, checking
+// again every interval. It never returns, so start it in its own goroutine.
+func (w *Worker) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.deleteExpiredExports()
+		<-ticker.C
+	}
+}
+
+func (w *Worker) deleteExpiredExports() {
+	exports, err := w.store.GetExpiredDataExports()
+	if err != nil {
+		log.Printf("failed to get expired data exports: %v", err)
+		return
+	}
+
+	for _, export := range exports {
+		if err := w.storage.DeleteFile(context.Background(), export.Filename); err != nil {
+			log.Printf("failed to delete data export %d of user %d: %v", export.ID, export.UserID, err)
+			continue
+		}
+
+		if err := w.store.ClearDataExportFile(export.ID); err != nil {
+			log.Printf("failed to clear data export %d: %v", export.ID, err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package follow
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/alissoncorsair/appsolidario-backend/service/auth"
+	"github.com/alissoncorsair/appsolidario-backend/service/notification"
+	"github.com/alissoncorsair/appsolidario-backend/service/session"
+	"github.com/alissoncorsair/appsolidario-backend/service/user"
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+)
+
+type Handler struct {
+	store             *Store
+	userStore         *user.Store
+	notificationStore *notification.Store
+	sessionStore      *session.Store
+}
+
+func NewHandler(store *Store, userStore *user.Store, notificationStore *notification.Store, sessionStore *session.Store) *Handler {
+	return &Handler{
+		store:             store,
+		userStore:         userStore,
+		notificationStore: notificationStore,
+		sessionStore:      sessionStore,
+	}
+}
+
+// HandleFollow follows a payee and lets them know, unless a notification of
+// an earlier follow is still unread.
+func (h *Handler) HandleFollow(w http.ResponseWriter, r *http.Request) {
+	followerID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	followeeID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user ID"))
+		return
+	}
+
+	if followeeID == followerID {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("você não pode seguir a si mesmo"))
+		return
+	}
+
+	followee, err := h.userStore.GetUserByID(followeeID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if followee == nil || followee.Status != types.StatusActive {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("user not found"))
+		return
+	}
+
+	if followee.RoleID != types.RolePayee {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("só é possível seguir beneficiários"))
+		return
+	}
+
+	blocked, err := h.userStore.IsBlocked(followeeID, followerID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if blocked {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("você não pode seguir este usuário"))
+		return
+	}
+
+	created, err := h.store.Follow(followerID, followeeID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if created {
+		if err := h.notificationStore.NotifyFollow(followeeID, followerID); err != nil {
+			log.Printf("failed to create follow notification: %v", err)
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Seguindo"})
+}
+
+func (h *Handler) HandleUnfollow(w http.ResponseWriter, r *http.Request) {
+	followerID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	followeeID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user ID"))
+		return
+	}
+
+	removed, err := h.store.Unfollow(followerID, followeeID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !removed {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("você não segue este usuário"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Deixou de seguir"})
+}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("POST /users/{id}/follow", auth.WithJWTAuth(h.HandleFollow, h.userStore, h.sessionStore))
+	router.HandleFunc("DELETE /users/{id}/follow", auth.WithJWTAuth(h.HandleUnfollow, h.userStore, h.sessionStore))
+}
@@ -0,0 +1,51 @@
+package follow
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+// Follow returns false when the user was already followed.
+func (s *Store) Follow(followerID int, followeeID int) (bool, error) {
+	query := `
+	INSERT INTO follows (follower_id, followee_id)
+	VALUES ($1, $2)
+	ON CONFLICT DO NOTHING
+	`
+	result, err := s.db.Exec(query, followerID, followeeID)
+	if err != nil {
+		return false, fmt.Errorf("error following user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// Unfollow returns false when the user wasn't followed.
+func (s *Store) Unfollow(followerID int, followeeID int) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2", followerID, followeeID)
+	if err != nil {
+		return false, fmt.Errorf("error unfollowing user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
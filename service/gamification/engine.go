@@ -0,0 +1,87 @@
+package gamification
+
+import (
+	"log"
+
+	"github.com/alissoncorsair/appsolidario-backend/service/leaderboard"
+	"github.com/alissoncorsair/appsolidario-backend/service/notification"
+	"github.com/alissoncorsair/appsolidario-backend/types"
+)
+
+// Engine turns platform events into points and notifies unlocked badges.
+// Failures are logged and never fail the action that triggered them.
+type Engine struct {
+	store             *Store
+	notificationStore *notification.Store
+	leaderboardStore  *leaderboard.Store
+}
+
+func NewEngine(store *Store, notificationStore *notification.Store, leaderboardStore *leaderboard.Store) *Engine {
+	return &Engine{
+		store:             store,
+		notificationStore: notificationStore,
+		leaderboardStore:  leaderboardStore,
+	}
+}
+
+// DonationConfirmed rewards the payer of a completed transaction.
+func (e *Engine) DonationConfirmed(transaction *types.Transaction) {
+	e.award(transaction.PayerID, types.PointsEventDonation, transaction.ID, DonationPoints(transaction.Amount))
+}
+
+// PostCreated rewards the first post of the user only.
+func (e *Engine) PostCreated(userID int) {
+	e.award(userID, types.PointsEventFirstPost, 0, PointsFirstPost)
+}
+
+// EmailVerified rewards the user and whoever referred them.
+func (e *Engine) EmailVerified(user *types.User) {
+	e.award(user.ID, types.PointsEventEmailVerified, 0, PointsEmailVerified)
+
+	if user.ReferredBy != nil {
+		e.award(*user.ReferredBy, types.PointsEventReferral, user.ID, PointsReferral)
+	}
+}
+
+func (e *Engine) Recompute(userID int) (int, error) {
+	points, badges, err := e.store.RecomputePoints(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	e.notify(userID, badges)
+
+	return points, nil
+}
+
+func (e *Engine) award(userID int, event types.PointsEvent, referenceID int, points int) {
+	awarded, badges, err := e.store.Award(userID, event, referenceID, points)
+	if err != nil {
+		log.Printf("failed to award %s points to user %d: %v", event, userID, err)
+		return
+	}
+
+	if awarded {
+		if err := e.leaderboardStore.RecordPoints(userID, points); err != nil {
+			log.Printf("failed to record points of user %d on the leaderboard: %v", userID, err)
+		}
+	}
+
+	e.notify(userID, badges)
+}
+
+func (e *Engine) notify(userID int, badges []*types.UserBadge) {
+	for _, badge := range badges {
+		_, err := e.notificationStore.CreateNotification(&types.Notification{
+			UserID:     userID,
+			FromUserID: userID,
+			Type:       types.TypeBadge,
+			ResourceID: badge.ID,
+			IsRead:     false,
+		})
+
+		if err != nil {
+			log.Printf("failed to create badge notification: %v", err)
+		}
+	}
+}
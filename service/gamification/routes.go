@@ -0,0 +1,118 @@
+package gamification
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/alissoncorsair/appsolidario-backend/service/auth"
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+)
+
+type Handler struct {
+	store        *Store
+	engine       *Engine
+	userStore    types.UserStore
+	sessionStore types.SessionStore
+}
+
+func NewHandler(store *Store, engine *Engine, userStore types.UserStore, sessionStore types.SessionStore) *Handler {
+	return &Handler{
+		store:        store,
+		engine:       engine,
+		userStore:    userStore,
+		sessionStore: sessionStore,
+	}
+}
+
+type PointsResponse struct {
+	Points int                                   `json:"points"`
+	Ledger *utils.Page[*types.PointsLedgerEntry] `json:"ledger"`
+}
+
+func (h *Handler) HandleGetOwnPoints(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	page, err := utils.ParsePageRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ledger, err := h.store.GetLedger(user.ID, page)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, PointsResponse{Points: user.Points, Ledger: ledger})
+}
+
+// HandleGetBadges lists the badges of a user. Users that hide their points
+// hide their badges too, except from themselves.
+func (h *Handler) HandleGetBadges(w http.ResponseWriter, r *http.Request) {
+	viewerID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	userID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user ID"))
+		return
+	}
+
+	if userID != viewerID {
+		show, err := h.store.ShowsPoints(userID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if !show {
+			utils.WriteJSON(w, http.StatusOK, []*types.UserBadge{})
+			return
+		}
+	}
+
+	badges, err := h.store.GetUserBadges(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, badges)
+}
+
+func (h *Handler) HandleGetBadgeDefinitions(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, http.StatusOK, Badges)
+}
+
+func (h *Handler) HandleRecomputePoints(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user ID"))
+		return
+	}
+
+	points, err := h.engine.Recompute(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]int{"points": points})
+}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /me/points", auth.WithJWTAuth(h.HandleGetOwnPoints, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /badges", auth.WithJWTAuth(h.HandleGetBadgeDefinitions, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /users/{id}/badges", auth.WithJWTAuth(h.HandleGetBadges, h.userStore, h.sessionStore))
+	router.HandleFunc("POST /admin/users/{id}/points/recompute", auth.WithJWTAuth(auth.WithRole(h.HandleRecomputePoints, types.RoleAdmin), h.userStore, h.sessionStore))
+}
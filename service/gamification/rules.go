@@ -0,0 +1,53 @@
+package gamification
+
+import (
+	"math"
+
+	"github.com/alissoncorsair/appsolidario-backend/types"
+)
+
+const (
+	PointsFirstPost     = 20
+	PointsEmailVerified = 10
+	PointsReferral      = 30
+
+	// donations give a fixed amount plus a point for every R$ 10
+	PointsDonation         = 10
+	PointsDonationPerReais = 10
+)
+
+// Badges must be sorted by threshold. Codes are stored in user_badges, so
+// they can't change once released.
+var Badges = []types.Badge{
+	{Code: "first_steps", Name: "Primeiros passos", Description: "Chegou aos 10 pontos", Threshold: 10},
+	{Code: "supporter", Name: "Solidário", Description: "Chegou aos 100 pontos", Threshold: 100},
+	{Code: "generous", Name: "Generoso", Description: "Chegou aos 500 pontos", Threshold: 500},
+	{Code: "community_hero", Name: "Herói da comunidade", Description: "Chegou aos 1000 pontos", Threshold: 1000},
+}
+
+func DonationPoints(amount float64) int {
+	return PointsDonation + int(math.Floor(amount/PointsDonationPerReais))
+}
+
+func FindBadge(code string) (types.Badge, bool) {
+	for _, badge := range Badges {
+		if badge.Code == code {
+			return badge, true
+		}
+	}
+
+	return types.Badge{}, false
+}
+
+// badgesFor returns the badges unlocked with the given points.
+func badgesFor(points int) []types.Badge {
+	var unlocked []types.Badge
+
+	for _, badge := range Badges {
+		if points >= badge.Threshold {
+			unlocked = append(unlocked, badge)
+		}
+	}
+
+	return unlocked
+}
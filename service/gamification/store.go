@@ -0,0 +1,204 @@
+package gamification
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+// Award adds the points to the ledger and to the user's balance and unlocks
+// the badges reached, all in one transaction. Awarding the same event and
+// reference twice does nothing, so callers can retry freely. It reports
+// whether the points were added, along with the badges they unlocked.
+func (s *Store) Award(userID int, event types.PointsEvent, referenceID int, points int) (bool, []*types.UserBadge, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO points_ledger (user_id, event, reference_id, points)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (user_id, event, reference_id) DO NOTHING
+	`
+	result, err := tx.Exec(query, userID, event, referenceID, points)
+	if err != nil {
+		return false, nil, fmt.Errorf("error adding to points ledger: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, nil, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return false, nil, nil
+	}
+
+	var total int
+	err = tx.QueryRow("UPDATE users SET points = points + $1 WHERE id = $2 RETURNING points", points, userID).Scan(&total)
+	if err != nil {
+		return false, nil, fmt.Errorf("error updating points: %w", err)
+	}
+
+	badges, err := unlockBadges(tx, userID, total)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return true, badges, nil
+}
+
+// RecomputePoints sets the user's balance back to the sum of their ledger and
+// unlocks any badge missing for it. Badges are never taken away.
+func (s *Store) RecomputePoints(userID int) (int, []*types.UserBadge, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	UPDATE users
+	SET points = (SELECT COALESCE(SUM(points), 0) FROM points_ledger WHERE user_id = $1)
+	WHERE id = $1
+	RETURNING points
+	`
+	var total int
+	if err := tx.QueryRow(query, userID).Scan(&total); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil, fmt.Errorf("user not found")
+		}
+		return 0, nil, fmt.Errorf("error recomputing points: %w", err)
+	}
+
+	badges, err := unlockBadges(tx, userID, total)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return total, badges, nil
+}
+
+func unlockBadges(tx *sql.Tx, userID int, points int) ([]*types.UserBadge, error) {
+	var unlocked []*types.UserBadge
+
+	query := `
+	INSERT INTO user_badges (user_id, badge)
+	VALUES ($1, $2)
+	ON CONFLICT (user_id, badge) DO NOTHING
+	RETURNING id, unlocked_at
+	`
+
+	for _, badge := range badgesFor(points) {
+		userBadge := &types.UserBadge{UserID: userID, Badge: badge}
+
+		err := tx.QueryRow(query, userID, badge.Code).Scan(&userBadge.ID, &userBadge.UnlockedAt)
+		if err == sql.ErrNoRows {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("error unlocking badge: %w", err)
+		}
+
+		unlocked = append(unlocked, userBadge)
+	}
+
+	return unlocked, nil
+}
+
+func (s *Store) GetLedger(userID int, page utils.PageRequest) (*utils.Page[*types.PointsLedgerEntry], error) {
+	query := `
+	SELECT id, user_id, event, reference_id, points, created_at
+	FROM points_ledger
+	WHERE user_id = $1
+	  AND ($2::timestamp IS NULL OR (created_at, id) < ($2, $3))
+	ORDER BY created_at DESC, id DESC
+	LIMIT $4
+	`
+	rows, err := s.db.Query(query, userID, page.AfterTime(), page.AfterID(), page.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error getting points ledger: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*types.PointsLedgerEntry
+	for rows.Next() {
+		var e types.PointsLedgerEntry
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Event, &e.ReferenceID, &e.Points, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning points ledger entry: %w", err)
+		}
+		entries = append(entries, &e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return utils.NewPage(entries, page.Limit, func(e *types.PointsLedgerEntry) utils.Cursor {
+		createdAt := e.CreatedAt
+		return utils.Cursor{Time: &createdAt, ID: e.ID}
+	}), nil
+}
+
+func (s *Store) GetUserBadges(userID int) ([]*types.UserBadge, error) {
+	rows, err := s.db.Query("SELECT id, user_id, badge, unlocked_at FROM user_badges WHERE user_id = $1 ORDER BY unlocked_at, id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting badges: %w", err)
+	}
+	defer rows.Close()
+
+	badges := []*types.UserBadge{}
+	for rows.Next() {
+		var b types.UserBadge
+		var code string
+		if err := rows.Scan(&b.ID, &b.UserID, &code, &b.UnlockedAt); err != nil {
+			return nil, fmt.Errorf("error scanning badge: %w", err)
+		}
+
+		badge, ok := FindBadge(code)
+		if !ok {
+			continue
+		}
+
+		b.Badge = badge
+		badges = append(badges, &b)
+	}
+
+	return badges, rows.Err()
+}
+
+// ShowsPoints tells whether the user lets others see their points, and so
+// their badges.
+func (s *Store) ShowsPoints(userID int) (bool, error) {
+	var show bool
+	query := `SELECT COALESCE((SELECT show_points FROM privacy_settings WHERE user_id = $1), TRUE)`
+
+	if err := s.db.QueryRow(query, userID).Scan(&show); err != nil {
+		return false, fmt.Errorf("error getting privacy settings: %w", err)
+	}
+
+	return show, nil
+}
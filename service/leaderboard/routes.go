@@ -0,0 +1,97 @@
+package leaderboard
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/alissoncorsair/appsolidario-backend/service/auth"
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+)
+
+type Handler struct {
+	store        *Store
+	userStore    types.UserStore
+	sessionStore types.SessionStore
+}
+
+func NewHandler(store *Store, userStore types.UserStore, sessionStore types.SessionStore) *Handler {
+	return &Handler{
+		store:        store,
+		userStore:    userStore,
+		sessionStore: sessionStore,
+	}
+}
+
+// HandleGetLeaderboard ranks the donors of the viewer's city, of their state
+// or of the whole country. The period defaults to the current month and the
+// metric to the amount donated.
+func (h *Handler) HandleGetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	query := r.URL.Query()
+
+	var city, state string
+	switch query.Get("scope") {
+	case "city":
+		city, state = user.City, user.State
+	case "state":
+		state = user.State
+	case "", "national":
+	default:
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid scope, expected city, state or national"))
+		return
+	}
+
+	period := Period(query.Get("period"))
+	switch period {
+	case "":
+		period = PeriodMonth
+	case PeriodWeek, PeriodMonth, PeriodAll:
+	default:
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid period, expected week, month or all"))
+		return
+	}
+
+	metric := Metric(query.Get("metric"))
+	switch metric {
+	case "":
+		metric = MetricAmount
+	case MetricAmount, MetricPoints:
+	default:
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid metric, expected amount or points"))
+		return
+	}
+
+	page, err := utils.ParsePageRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	leaderboard, err := h.store.GetLeaderboard(period, metric, city, state, page)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, leaderboard)
+}
+
+func (h *Handler) HandleRebuild(w http.ResponseWriter, r *http.Request) {
+	if err := h.store.Rebuild(); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "leaderboard rebuilt"})
+}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /leaderboard", auth.WithJWTAuth(h.HandleGetLeaderboard, h.userStore, h.sessionStore))
+	router.HandleFunc("POST /admin/leaderboard/rebuild", auth.WithJWTAuth(auth.WithRole(h.HandleRebuild, types.RoleAdmin), h.userStore, h.sessionStore))
+}
@@ -0,0 +1,184 @@
+package leaderboard
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+)
+
+type Period string
+
+const (
+	PeriodWeek  Period = "week"
+	PeriodMonth Period = "month"
+	PeriodAll   Period = "all"
+)
+
+type Metric string
+
+const (
+	MetricAmount Metric = "amount"
+	MetricPoints Metric = "points"
+)
+
+// currentPeriods are the donor_stats rows an event happening now counts for.
+const currentPeriods = `(VALUES
+	('week', date_trunc('week', NOW())::date),
+	('month', date_trunc('month', NOW())::date),
+	('all', DATE '1970-01-01')
+) AS p(period, period_start)`
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+// RecordDonation adds a confirmed donation to the payer's current stats.
+func (s *Store) RecordDonation(userID int, amount float64) error {
+	query := `
+	INSERT INTO donor_stats (user_id, period, period_start, amount, donations)
+	SELECT $1, p.period, p.period_start, $2, 1
+	FROM ` + currentPeriods + `
+	ON CONFLICT (user_id, period, period_start) DO UPDATE
+	SET amount = donor_stats.amount + EXCLUDED.amount, donations = donor_stats.donations + 1
+	`
+	if _, err := s.db.Exec(query, userID, amount); err != nil {
+		return fmt.Errorf("error recording donation stats: %w", err)
+	}
+
+	return nil
+}
+
+// RecordPoints adds awarded points to the user's current stats.
+func (s *Store) RecordPoints(userID int, points int) error {
+	query := `
+	INSERT INTO donor_stats (user_id, period, period_start, points)
+	SELECT $1, p.period, p.period_start, $2
+	FROM ` + currentPeriods + `
+	ON CONFLICT (user_id, period, period_start) DO UPDATE
+	SET points = donor_stats.points + EXCLUDED.points
+	`
+	if _, err := s.db.Exec(query, userID, points); err != nil {
+		return fmt.Errorf("error recording points stats: %w", err)
+	}
+
+	return nil
+}
+
+// Rebuild recomputes every donor_stats row from the confirmed transactions
+// and the points ledger, in case they ever drift apart.
+func (s *Store) Rebuild() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM donor_stats"); err != nil {
+		return fmt.Errorf("error clearing donor stats: %w", err)
+	}
+
+	query := `
+	INSERT INTO donor_stats (user_id, period, period_start, amount, donations, points)
+	SELECT user_id, period, period_start, SUM(amount), SUM(donations), SUM(points)
+	FROM (
+		SELECT t.payer_id AS user_id, p.period, p.period_start, t.amount, 1 AS donations, 0 AS points
+		FROM transactions t
+		CROSS JOIN LATERAL (VALUES
+			('week', date_trunc('week', t.updated_at)::date),
+			('month', date_trunc('month', t.updated_at)::date),
+			('all', DATE '1970-01-01')
+		) AS p(period, period_start)
+		WHERE t.status = $1
+		UNION ALL
+		SELECT l.user_id, p.period, p.period_start, 0, 0, l.points
+		FROM points_ledger l
+		CROSS JOIN LATERAL (VALUES
+			('week', date_trunc('week', l.created_at)::date),
+			('month', date_trunc('month', l.created_at)::date),
+			('all', DATE '1970-01-01')
+		) AS p(period, period_start)
+	) events
+	GROUP BY user_id, period, period_start
+	`
+	if _, err := tx.Exec(query, types.StatusDone); err != nil {
+		return fmt.Errorf("error rebuilding donor stats: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetLeaderboard ranks the active donors of the current period by metric.
+// city and state narrow it down when set; donors that opted out, or hide the
+// city or state being filtered on, are left out.
+func (s *Store) GetLeaderboard(period Period, metric Metric, city string, state string, page utils.PageRequest) (*utils.Page[*types.LeaderboardEntry], error) {
+	// metric is one of our constants, never user input
+	query := fmt.Sprintf(`
+	SELECT * FROM (
+		SELECT u.id, u.name, u.surname, pp.path,
+		       CASE WHEN COALESCE(ps.show_city, TRUE) THEN u.city ELSE '' END AS city,
+		       CASE WHEN COALESCE(ps.show_state, TRUE) THEN u.state ELSE '' END AS state,
+		       ds.amount::float8, ds.donations, ds.points,
+		       ds.%[1]s::float8 AS score,
+		       RANK() OVER (ORDER BY ds.%[1]s DESC) AS rank
+		FROM donor_stats ds
+		JOIN users u ON ds.user_id = u.id
+		LEFT JOIN profile_pictures pp ON u.id = pp.user_id
+		LEFT JOIN privacy_settings ps ON u.id = ps.user_id
+		WHERE ds.period = $1
+		  AND ds.period_start = (SELECT period_start FROM `+currentPeriods+` WHERE p.period = $1)
+		  AND ds.%[1]s > 0
+		  AND u.status = $2
+		  AND COALESCE(ps.show_on_leaderboard, TRUE)
+		  AND ($3 = '' OR (u.city = $3 AND COALESCE(ps.show_city, TRUE)))
+		  AND ($4 = '' OR (u.state = $4 AND COALESCE(ps.show_state, TRUE)))
+	) ranked
+	WHERE $5::float8 IS NULL OR score < $5 OR (score = $5 AND id > $6)
+	ORDER BY score DESC, id
+	LIMIT $7
+	`, metric)
+
+	rows, err := s.db.Query(query, period, types.StatusActive, city, state, page.AfterValue(), page.AfterID(), page.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error getting leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*types.LeaderboardEntry
+	var scores = map[int]float64{}
+	for rows.Next() {
+		var e types.LeaderboardEntry
+		var path sql.NullString
+		var score float64
+		if err := rows.Scan(&e.UserID, &e.Name, &e.Surname, &path, &e.City, &e.State, &e.Amount, &e.Donations, &e.Points, &score, &e.Rank); err != nil {
+			return nil, fmt.Errorf("error scanning leaderboard entry: %w", err)
+		}
+
+		if path.Valid {
+			e.UserPicture = path.String
+		}
+
+		scores[e.UserID] = score
+		entries = append(entries, &e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return utils.NewPage(entries, page.Limit, func(e *types.LeaderboardEntry) utils.Cursor {
+		score := scores[e.UserID]
+		return utils.Cursor{Value: &score, ID: e.UserID}
+	}), nil
+}
@@ -0,0 +1,96 @@
+package login_attempt
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/alissoncorsair/appsolidario-backend/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+func CPFKey(cpf string) string {
+	return "cpf:" + cpf
+}
+
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+func ScanRowIntoLoginAttempt(row *sql.Row) (*types.LoginAttempt, error) {
+	var a types.LoginAttempt
+	err := row.Scan(&a.Key, &a.Failures, &a.LastFailureAt, &a.BlockedUntil)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+func (s *Store) GetLoginAttempt(key string) (*types.LoginAttempt, error) {
+	query := `
+		SELECT key, failures, last_failure_at, blocked_until
+		FROM login_attempts
+		WHERE key = $1
+	`
+
+	return ScanRowIntoLoginAttempt(s.db.QueryRow(query, key))
+}
+
+// RegisterFailure increments the failure counter for the key. Failures older
+// than window are forgotten, so the counter starts over.
+func (s *Store) RegisterFailure(key string, window time.Duration) (*types.LoginAttempt, error) {
+	query := `
+		INSERT INTO login_attempts (key, failures, last_failure_at)
+		VALUES ($1, 1, NOW())
+		ON CONFLICT (key) DO UPDATE
+		SET failures = CASE
+				WHEN login_attempts.last_failure_at < NOW() - make_interval(secs => $2) THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			last_failure_at = NOW()
+		RETURNING key, failures, last_failure_at, blocked_until
+	`
+
+	attempt, err := ScanRowIntoLoginAttempt(s.db.QueryRow(query, key, window.Seconds()))
+
+	if err != nil {
+		return nil, fmt.Errorf("error registering login failure: %w", err)
+	}
+
+	return attempt, nil
+}
+
+func (s *Store) BlockUntil(key string, until time.Time) error {
+	_, err := s.db.Exec("UPDATE login_attempts SET blocked_until = $1 WHERE key = $2", until, key)
+
+	if err != nil {
+		return fmt.Errorf("error blocking login: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) ResetLoginAttempts(key string) error {
+	_, err := s.db.Exec("DELETE FROM login_attempts WHERE key = $1", key)
+
+	if err != nil {
+		return fmt.Errorf("error resetting login attempts: %w", err)
+	}
+
+	return nil
+}
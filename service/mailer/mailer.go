@@ -1,8 +1,17 @@
 package mailer
 
-import "github.com/alissoncorsair/appsolidario-backend/types"
+import (
+	"time"
+
+	"github.com/alissoncorsair/appsolidario-backend/types"
+)
 
 type Mailer interface {
 	SendConfirmationEmail(user *types.User, token string) error
 	SendPaymentThanksEmail(user *types.User, amount float64) error
+	SendPasswordResetEmail(user *types.User, token string) error
+	SendAccountLockedEmail(user *types.User, token string, lockedUntil time.Time) error
+	SendEmailChangeEmail(user *types.User, newEmail string, token string) error
+	SendEmailChangedNotice(user *types.User, newEmail string) error
+	SendVerificationStatusEmail(user *types.User, request *types.VerificationRequest) error
 }
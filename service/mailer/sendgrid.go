@@ -110,6 +110,163 @@ func (m *SendGridMailer) SendPaymentThanksEmail(user *types.User, amount float64
 	return nil
 }
 
+func (m *SendGridMailer) SendPasswordResetEmail(user *types.User, token string) error {
+	if m.DevMode {
+		fmt.Printf("Development mode: Email not sent. User: %s, Reset token: %s\n", user.Email, token)
+		return nil
+	}
+
+	from := mail.NewEmail(FromName, m.From)
+	subject := "Redefinição de senha"
+	userName := fmt.Sprintf("%s %s", user.Name, user.Surname)
+	to := mail.NewEmail(userName, user.Email)
+
+	htmlContent, err := BuildPasswordResetEmail(user, token)
+	if err != nil {
+		return fmt.Errorf("failed to build password reset email: %w", err)
+	}
+
+	message := mail.NewSingleEmail(from, subject, to, "", htmlContent)
+
+	response, err := m.Client.Send(message)
+
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("failed to send email: %s", response.Body)
+	}
+
+	return nil
+}
+
+func (m *SendGridMailer) SendAccountLockedEmail(user *types.User, token string, lockedUntil time.Time) error {
+	if m.DevMode {
+		fmt.Printf("Development mode: Email not sent. User: %s, Unlock token: %s\n", user.Email, token)
+		return nil
+	}
+
+	from := mail.NewEmail(FromName, m.From)
+	subject := "Sua conta foi bloqueada temporariamente"
+	userName := fmt.Sprintf("%s %s", user.Name, user.Surname)
+	to := mail.NewEmail(userName, user.Email)
+
+	htmlContent, err := BuildAccountLockedEmail(user, token, lockedUntil)
+	if err != nil {
+		return fmt.Errorf("failed to build account locked email: %w", err)
+	}
+
+	message := mail.NewSingleEmail(from, subject, to, "", htmlContent)
+
+	response, err := m.Client.Send(message)
+
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("failed to send email: %s", response.Body)
+	}
+
+	return nil
+}
+
+func (m *SendGridMailer) SendEmailChangeEmail(user *types.User, newEmail string, token string) error {
+	if m.DevMode {
+		fmt.Printf("Development mode: Email not sent. User: %s, New email: %s, Token: %s\n", user.Email, newEmail, token)
+		return nil
+	}
+
+	from := mail.NewEmail(FromName, m.From)
+	subject := "Confirme seu novo email"
+	userName := fmt.Sprintf("%s %s", user.Name, user.Surname)
+	to := mail.NewEmail(userName, newEmail)
+
+	htmlContent, err := BuildEmailChangeEmail(user, token)
+	if err != nil {
+		return fmt.Errorf("failed to build email change email: %w", err)
+	}
+
+	message := mail.NewSingleEmail(from, subject, to, "", htmlContent)
+
+	response, err := m.Client.Send(message)
+
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("failed to send email: %s", response.Body)
+	}
+
+	return nil
+}
+
+// SendEmailChangedNotice tells the user, at the address they are leaving,
+// that the email of their account was changed.
+func (m *SendGridMailer) SendEmailChangedNotice(user *types.User, newEmail string) error {
+	if m.DevMode {
+		fmt.Printf("Development mode: Email not sent. User: %s, Email changed to: %s\n", user.Email, newEmail)
+		return nil
+	}
+
+	from := mail.NewEmail(FromName, m.From)
+	subject := "O email da sua conta foi alterado"
+	userName := fmt.Sprintf("%s %s", user.Name, user.Surname)
+	to := mail.NewEmail(userName, user.Email)
+
+	htmlContent, err := BuildEmailChangedNotice(user, newEmail)
+	if err != nil {
+		return fmt.Errorf("failed to build email changed notice: %w", err)
+	}
+
+	message := mail.NewSingleEmail(from, subject, to, "", htmlContent)
+
+	response, err := m.Client.Send(message)
+
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("failed to send email: %s", response.Body)
+	}
+
+	return nil
+}
+
+func (m *SendGridMailer) SendVerificationStatusEmail(user *types.User, request *types.VerificationRequest) error {
+	if m.DevMode {
+		fmt.Printf("Development mode: Email not sent. User: %s, Verification status: %s\n", user.Email, request.Status)
+		return nil
+	}
+
+	from := mail.NewEmail(FromName, m.From)
+	subject := verificationSubjects[request.Status]
+	userName := fmt.Sprintf("%s %s", user.Name, user.Surname)
+	to := mail.NewEmail(userName, user.Email)
+
+	htmlContent, err := BuildVerificationStatusEmail(user, request)
+	if err != nil {
+		return fmt.Errorf("failed to build verification status email: %w", err)
+	}
+
+	message := mail.NewSingleEmail(from, subject, to, "", htmlContent)
+
+	response, err := m.Client.Send(message)
+
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("failed to send email: %s", response.Body)
+	}
+
+	return nil
+}
+
 func BuildConfirmationEmail(user *types.User, token string) (string, error) {
 	templ, err := template.ParseFiles("service/mailer/templates/mail-confirmation.templ")
 	if err != nil {
@@ -137,3 +294,158 @@ func BuildConfirmationEmail(user *types.User, token string) (string, error) {
 
 	return body.String(), nil
 }
+
+func BuildPasswordResetEmail(user *types.User, token string) (string, error) {
+	templ, err := template.ParseFiles("service/mailer/templates/password-reset.templ")
+	if err != nil {
+		return "", err
+	}
+
+	URL := fmt.Sprintf("%s?token=%s", config.Envs.EmailResetPasswordUrl, token)
+
+	payload := struct {
+		User        *types.User
+		URL         string
+		ExpiresIn   int64
+		CurrentYear int
+	}{
+		User:        user,
+		URL:         URL,
+		ExpiresIn:   config.Envs.PasswordResetExpirationInSeconds / 60,
+		CurrentYear: time.Now().Year(),
+	}
+
+	var body bytes.Buffer
+	err = templ.Execute(&body, payload)
+
+	if err != nil {
+		return "", err
+	}
+
+	return body.String(), nil
+}
+
+func BuildAccountLockedEmail(user *types.User, token string, lockedUntil time.Time) (string, error) {
+	templ, err := template.ParseFiles("service/mailer/templates/account-locked.templ")
+	if err != nil {
+		return "", err
+	}
+
+	URL := fmt.Sprintf("%s?token=%s", config.Envs.EmailUnlockAccountUrl, token)
+
+	payload := struct {
+		User        *types.User
+		URL         string
+		LockedUntil string
+		CurrentYear int
+	}{
+		User:        user,
+		URL:         URL,
+		LockedUntil: lockedUntil.Format("02/01/2006 15:04"),
+		CurrentYear: time.Now().Year(),
+	}
+
+	var body bytes.Buffer
+	err = templ.Execute(&body, payload)
+
+	if err != nil {
+		return "", err
+	}
+
+	return body.String(), nil
+}
+
+func BuildEmailChangeEmail(user *types.User, token string) (string, error) {
+	templ, err := template.ParseFiles("service/mailer/templates/email-change.templ")
+	if err != nil {
+		return "", err
+	}
+
+	URL := fmt.Sprintf("%s?token=%s", config.Envs.EmailVerifyUrl, token)
+
+	payload := struct {
+		User        *types.User
+		URL         string
+		CurrentYear int
+	}{
+		User:        user,
+		URL:         URL,
+		CurrentYear: time.Now().Year(),
+	}
+
+	var body bytes.Buffer
+	err = templ.Execute(&body, payload)
+
+	if err != nil {
+		return "", err
+	}
+
+	return body.String(), nil
+}
+
+func BuildEmailChangedNotice(user *types.User, newEmail string) (string, error) {
+	templ, err := template.ParseFiles("service/mailer/templates/email-changed.templ")
+	if err != nil {
+		return "", err
+	}
+
+	payload := struct {
+		User        *types.User
+		NewEmail    string
+		CurrentYear int
+	}{
+		User:        user,
+		NewEmail:    newEmail,
+		CurrentYear: time.Now().Year(),
+	}
+
+	var body bytes.Buffer
+	err = templ.Execute(&body, payload)
+
+	if err != nil {
+		return "", err
+	}
+
+	return body.String(), nil
+}
+
+var verificationSubjects = map[types.VerificationStatus]string{
+	types.VerificationPending:  "Recebemos seus documentos",
+	types.VerificationApproved: "Sua conta foi verificada",
+	types.VerificationRejected: "Não foi possível verificar sua conta",
+}
+
+func BuildVerificationStatusEmail(user *types.User, request *types.VerificationRequest) (string, error) {
+	templ, err := template.ParseFiles("service/mailer/templates/verification-status.templ")
+	if err != nil {
+		return "", err
+	}
+
+	reason := ""
+	if request.RejectionReason != nil {
+		reason = *request.RejectionReason
+	}
+
+	payload := struct {
+		User        *types.User
+		Request     *types.VerificationRequest
+		Subject     string
+		Reason      string
+		CurrentYear int
+	}{
+		User:        user,
+		Request:     request,
+		Subject:     verificationSubjects[request.Status],
+		Reason:      reason,
+		CurrentYear: time.Now().Year(),
+	}
+
+	var body bytes.Buffer
+	err = templ.Execute(&body, payload)
+
+	if err != nil {
+		return "", err
+	}
+
+	return body.String(), nil
+}
@@ -0,0 +1,153 @@
+package mfa
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/alissoncorsair/appsolidario-backend/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+func ScanRowIntoUserMFA(row *sql.Row) (*types.UserMFA, error) {
+	var m types.UserMFA
+	err := row.Scan(&m.UserID, &m.Secret, &m.Enabled, &m.LastUsedStep, &m.CreatedAt, &m.EnabledAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+func (s *Store) GetUserMFA(userID int) (*types.UserMFA, error) {
+	query := `
+		SELECT user_id, secret, enabled, last_used_step, created_at, enabled_at
+		FROM user_mfa
+		WHERE user_id = $1
+	`
+
+	return ScanRowIntoUserMFA(s.db.QueryRow(query, userID))
+}
+
+// CreateUserMFA starts a new (not yet enabled) enrollment, replacing any
+// previous unconfirmed one together with its recovery codes.
+func (s *Store) CreateUserMFA(userID int, secret string, recoveryCodeHashes []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO user_mfa (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, enabled = FALSE, last_used_step = 0, created_at = NOW(), enabled_at = NULL
+	`
+	if _, err = tx.Exec(query, userID, secret); err != nil {
+		return fmt.Errorf("error creating mfa: %w", err)
+	}
+
+	if _, err = tx.Exec("DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("error deleting recovery codes: %w", err)
+	}
+
+	for _, hash := range recoveryCodeHashes {
+		_, err = tx.Exec("INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)", userID, hash)
+		if err != nil {
+			return fmt.Errorf("error adding recovery code: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) EnableUserMFA(userID int) error {
+	_, err := s.db.Exec("UPDATE user_mfa SET enabled = TRUE, enabled_at = NOW() WHERE user_id = $1", userID)
+
+	if err != nil {
+		return fmt.Errorf("error enabling mfa: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteUserMFA(userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec("DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("error deleting recovery codes: %w", err)
+	}
+
+	if _, err = tx.Exec("DELETE FROM user_mfa WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("error deleting mfa: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UseStep records the time step of an accepted code. It returns false when
+// that step (or a later one) was already used, which blocks code replay.
+func (s *Store) UseStep(userID int, step int64) (bool, error) {
+	result, err := s.db.Exec("UPDATE user_mfa SET last_used_step = $1 WHERE user_id = $2 AND last_used_step < $1", step, userID)
+	if err != nil {
+		return false, fmt.Errorf("error updating mfa step: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// UseRecoveryCode consumes an unused recovery code and reports whether one
+// matched.
+func (s *Store) UseRecoveryCode(userID int, codeHash string) (bool, error) {
+	query := `
+		UPDATE mfa_recovery_codes
+		SET used_at = NOW()
+		WHERE id = (
+			SELECT id FROM mfa_recovery_codes
+			WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+			LIMIT 1
+		)
+	`
+	result, err := s.db.Exec(query, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("error using recovery code: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
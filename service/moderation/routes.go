@@ -0,0 +1,286 @@
+package moderation
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alissoncorsair/appsolidario-backend/service/auth"
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+	"github.com/go-playground/validator/v10"
+)
+
+type Handler struct {
+	store        *Store
+	userStore    types.UserStore
+	sessionStore types.SessionStore
+}
+
+func NewHandler(store *Store, userStore types.UserStore, sessionStore types.SessionStore) *Handler {
+	return &Handler{
+		store:        store,
+		userStore:    userStore,
+		sessionStore: sessionStore,
+	}
+}
+
+// targetFromPath reads the user in the path, which must exist and be
+// someone other than the viewer.
+func (h *Handler) targetFromPath(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	viewerID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return 0, 0, false
+	}
+
+	targetID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user ID"))
+		return 0, 0, false
+	}
+
+	if targetID == viewerID {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("você não pode fazer isso com você mesmo"))
+		return 0, 0, false
+	}
+
+	target, err := h.userStore.GetUserByID(targetID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return 0, 0, false
+	}
+
+	if target == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("user not found"))
+		return 0, 0, false
+	}
+
+	return viewerID, targetID, true
+}
+
+func (h *Handler) HandleBlock(w http.ResponseWriter, r *http.Request) {
+	viewerID, targetID, ok := h.targetFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.store.Block(viewerID, targetID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Usuário bloqueado"})
+}
+
+func (h *Handler) HandleUnblock(w http.ResponseWriter, r *http.Request) {
+	viewerID, targetID, ok := h.targetFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	removed, err := h.store.Unblock(viewerID, targetID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !removed {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("você não bloqueou este usuário"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Usuário desbloqueado"})
+}
+
+func (h *Handler) HandleMute(w http.ResponseWriter, r *http.Request) {
+	viewerID, targetID, ok := h.targetFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.store.Mute(viewerID, targetID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Usuário silenciado"})
+}
+
+func (h *Handler) HandleUnmute(w http.ResponseWriter, r *http.Request) {
+	viewerID, targetID, ok := h.targetFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	removed, err := h.store.Unmute(viewerID, targetID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !removed {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("você não silenciou este usuário"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Usuário não está mais silenciado"})
+}
+
+func (h *Handler) HandleGetBlocked(w http.ResponseWriter, r *http.Request) {
+	h.listModerated(w, r, h.store.GetBlockedUsers)
+}
+
+func (h *Handler) HandleGetMuted(w http.ResponseWriter, r *http.Request) {
+	h.listModerated(w, r, h.store.GetMutedUsers)
+}
+
+func (h *Handler) listModerated(w http.ResponseWriter, r *http.Request, list func(int, utils.PageRequest) (*utils.Page[*types.ModeratedUser], error)) {
+	userID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	page, err := utils.ParsePageRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	users, err := list(userID, page)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, users)
+}
+
+// HandleReport files a report against a user for the admins to review,
+// optionally blocking them right away.
+func (h *Handler) HandleReport(w http.ResponseWriter, r *http.Request) {
+	var payload types.CreateReportRequest
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	viewerID, targetID, ok := h.targetFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	if payload.Details != nil {
+		details := strings.TrimSpace(*payload.Details)
+		payload.Details = &details
+	}
+
+	report, err := h.store.CreateReport(&types.Report{
+		ReporterID: viewerID,
+		ReportedID: targetID,
+		Reason:     payload.Reason,
+		Details:    payload.Details,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if payload.Block {
+		if _, err := h.store.Block(viewerID, targetID); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, report)
+}
+
+// HandleGetReports is the admin queue of reports, the open ones unless
+// another status is asked for.
+func (h *Handler) HandleGetReports(w http.ResponseWriter, r *http.Request) {
+	status := types.ReportStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "":
+		status = types.ReportOpen
+	case types.ReportOpen, types.ReportResolved, types.ReportDismissed:
+	default:
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid status, expected open, resolved or dismissed"))
+		return
+	}
+
+	page, err := utils.ParsePageRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	reports, err := h.store.GetReports(status, page)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, reports)
+}
+
+func (h *Handler) HandleResolveReport(w http.ResponseWriter, r *http.Request) {
+	adminID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid report ID"))
+		return
+	}
+
+	var payload types.ResolveReportRequest
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	report, err := h.store.ResolveReport(id, adminID, payload.Status)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if report == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("open report not found"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, report)
+}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("POST /users/{id}/block", auth.WithJWTAuth(h.HandleBlock, h.userStore, h.sessionStore))
+	router.HandleFunc("DELETE /users/{id}/block", auth.WithJWTAuth(h.HandleUnblock, h.userStore, h.sessionStore))
+	router.HandleFunc("POST /users/{id}/mute", auth.WithJWTAuth(h.HandleMute, h.userStore, h.sessionStore))
+	router.HandleFunc("DELETE /users/{id}/mute", auth.WithJWTAuth(h.HandleUnmute, h.userStore, h.sessionStore))
+	router.HandleFunc("POST /users/{id}/report", auth.WithJWTAuth(h.HandleReport, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /me/blocks", auth.WithJWTAuth(h.HandleGetBlocked, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /me/mutes", auth.WithJWTAuth(h.HandleGetMuted, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /admin/reports", auth.WithJWTAuth(auth.WithRole(h.HandleGetReports, types.RoleAdmin), h.userStore, h.sessionStore))
+	router.HandleFunc("POST /admin/reports/{id}/resolve", auth.WithJWTAuth(auth.WithRole(h.HandleResolveReport, types.RoleAdmin), h.userStore, h.sessionStore))
+}
@@ -0,0 +1,17 @@
+package moderation
+
+import "fmt"
+
+// NotBlockedSQL is a condition that holds unless the viewer (a query
+// parameter such as "$1") blocked the user in userColumn.
+func NotBlockedSQL(userColumn string, viewerParam string) string {
+	return fmt.Sprintf("NOT EXISTS (SELECT 1 FROM user_blocks WHERE blocker_id = %s AND blocked_id = %s)", viewerParam, userColumn)
+}
+
+// NotHiddenSQL is like NotBlockedSQL but also leaves out the users the
+// viewer muted. Posts and notifications use it, users and comments only
+// honor blocks.
+func NotHiddenSQL(userColumn string, viewerParam string) string {
+	return NotBlockedSQL(userColumn, viewerParam) +
+		fmt.Sprintf(" AND NOT EXISTS (SELECT 1 FROM user_mutes WHERE muter_id = %s AND muted_id = %s)", viewerParam, userColumn)
+}
@@ -0,0 +1,223 @@
+package moderation
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+// Block returns false when the user was already blocked. Blocking also ends
+// the follows between both users, in either direction.
+func (s *Store) Block(blockerID int, blockedID int) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO user_blocks (blocker_id, blocked_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", blockerID, blockedID)
+	if err != nil {
+		return false, fmt.Errorf("error blocking user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	query := `
+	DELETE FROM follows
+	WHERE (follower_id = $1 AND followee_id = $2) OR (follower_id = $2 AND followee_id = $1)
+	`
+	if _, err := tx.Exec(query, blockerID, blockedID); err != nil {
+		return false, fmt.Errorf("error removing follows: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// Unblock returns false when the user wasn't blocked.
+func (s *Store) Unblock(blockerID int, blockedID int) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2", blockerID, blockedID)
+	if err != nil {
+		return false, fmt.Errorf("error unblocking user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// Mute returns false when the user was already muted.
+func (s *Store) Mute(muterID int, mutedID int) (bool, error) {
+	result, err := s.db.Exec("INSERT INTO user_mutes (muter_id, muted_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", muterID, mutedID)
+	if err != nil {
+		return false, fmt.Errorf("error muting user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// Unmute returns false when the user wasn't muted.
+func (s *Store) Unmute(muterID int, mutedID int) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM user_mutes WHERE muter_id = $1 AND muted_id = $2", muterID, mutedID)
+	if err != nil {
+		return false, fmt.Errorf("error unmuting user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (s *Store) GetBlockedUsers(userID int, page utils.PageRequest) (*utils.Page[*types.ModeratedUser], error) {
+	return s.getModeratedUsers("user_blocks", "blocker_id", "blocked_id", userID, page)
+}
+
+func (s *Store) GetMutedUsers(userID int, page utils.PageRequest) (*utils.Page[*types.ModeratedUser], error) {
+	return s.getModeratedUsers("user_mutes", "muter_id", "muted_id", userID, page)
+}
+
+// getModeratedUsers lists, newest first, the users in targetColumn of table
+// for the user in ownerColumn. The identifiers are never user input.
+func (s *Store) getModeratedUsers(table string, ownerColumn string, targetColumn string, userID int, page utils.PageRequest) (*utils.Page[*types.ModeratedUser], error) {
+	query := fmt.Sprintf(`
+	SELECT u.id, u.name, u.surname, pp.path, m.created_at
+	FROM %[1]s m
+	JOIN users u ON m.%[3]s = u.id
+	LEFT JOIN profile_pictures pp ON u.id = pp.user_id
+	WHERE m.%[2]s = $1
+	  AND ($2::timestamp IS NULL OR (m.created_at, u.id) < ($2, $3))
+	ORDER BY m.created_at DESC, u.id DESC
+	LIMIT $4
+	`, table, ownerColumn, targetColumn)
+
+	rows, err := s.db.Query(query, userID, page.AfterTime(), page.AfterID(), page.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	users := []*types.ModeratedUser{}
+	for rows.Next() {
+		var u types.ModeratedUser
+		var path sql.NullString
+		if err := rows.Scan(&u.UserID, &u.Name, &u.Surname, &path, &u.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning user: %w", err)
+		}
+
+		if path.Valid {
+			u.UserPicture = path.String
+		}
+
+		users = append(users, &u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return utils.NewPage(users, page.Limit, func(u *types.ModeratedUser) utils.Cursor {
+		return utils.Cursor{Time: &u.CreatedAt, ID: u.UserID}
+	}), nil
+}
+
+func (s *Store) CreateReport(report *types.Report) (*types.Report, error) {
+	query := `
+	INSERT INTO reports (reporter_id, reported_id, reason, details)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id, reporter_id, reported_id, reason, details, status, resolved_by, resolved_at, created_at
+	`
+
+	return scanReport(s.db.QueryRow(query, report.ReporterID, report.ReportedID, report.Reason, report.Details))
+}
+
+// GetReports lists the reports with the given status, oldest first.
+func (s *Store) GetReports(status types.ReportStatus, page utils.PageRequest) (*utils.Page[*types.Report], error) {
+	query := `
+	SELECT id, reporter_id, reported_id, reason, details, status, resolved_by, resolved_at, created_at
+	FROM reports
+	WHERE status = $1
+	  AND ($2::timestamp IS NULL OR (created_at, id) > ($2, $3))
+	ORDER BY created_at, id
+	LIMIT $4
+	`
+	rows, err := s.db.Query(query, status, page.AfterTime(), page.AfterID(), page.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error getting reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports := []*types.Report{}
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return utils.NewPage(reports, page.Limit, func(r *types.Report) utils.Cursor {
+		return utils.Cursor{Time: &r.CreatedAt, ID: r.ID}
+	}), nil
+}
+
+// ResolveReport closes an open report. It returns nil when the report
+// doesn't exist or was already closed.
+func (s *Store) ResolveReport(id int, adminID int, status types.ReportStatus) (*types.Report, error) {
+	query := `
+	UPDATE reports
+	SET status = $1, resolved_by = $2, resolved_at = NOW()
+	WHERE id = $3 AND status = $4
+	RETURNING id, reporter_id, reported_id, reason, details, status, resolved_by, resolved_at, created_at
+	`
+
+	return scanReport(s.db.QueryRow(query, status, adminID, id, types.ReportOpen))
+}
+
+func scanReport(scanner interface{ Scan(...any) error }) (*types.Report, error) {
+	var r types.Report
+	err := scanner.Scan(&r.ID, &r.ReporterID, &r.ReportedID, &r.Reason, &r.Details, &r.Status, &r.ResolvedBy, &r.ResolvedAt, &r.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error scanning report: %w", err)
+	}
+
+	return &r, nil
+}
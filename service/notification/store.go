@@ -2,9 +2,12 @@ package notification
 
 import (
 	"database/sql"
+	"fmt"
 	"time"
 
+	"github.com/alissoncorsair/appsolidario-backend/service/moderation"
 	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
 )
 
 type Store struct {
@@ -19,7 +22,7 @@ func NewStore(db *sql.DB) *Store {
 
 func ScanRowIntoNotification(row *sql.Row) (*types.Notification, error) {
 	var n types.Notification
-	err := row.Scan(&n.ID, &n.UserID, &n.Type, &n.ResourceID, &n.IsRead, &n.CreatedAt, &n.UpdatedAt)
+	err := row.Scan(&n.ID, &n.UserID, &n.Type, &n.ResourceID, &n.IsRead, &n.CreatedAt, &n.UpdatedAt, &n.Count)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -36,7 +39,7 @@ func ScanRowsIntoNotifications(rows *sql.Rows) ([]*types.Notification, error) {
 
 	for rows.Next() {
 		var n types.Notification
-		err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.ResourceID, &n.IsRead, &n.CreatedAt, &n.UpdatedAt)
+		err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.ResourceID, &n.IsRead, &n.CreatedAt, &n.UpdatedAt, &n.Count)
 		if err != nil {
 			return nil, err
 		}
@@ -51,14 +54,50 @@ func ScanRowsIntoNotifications(rows *sql.Rows) ([]*types.Notification, error) {
 }
 
 func (s *Store) CreateNotification(notification *types.Notification) (*types.Notification, error) {
-	query := `INSERT INTO notifications (user_id, type, from_user_id, resource_id, is_read) VALUES ($1, $2, $3, $4, $5) RETURNING id, user_id, type, resource_id, is_read, created_at, updated_at`
+	query := `INSERT INTO notifications (user_id, type, from_user_id, resource_id, is_read) VALUES ($1, $2, $3, $4, $5) RETURNING id, user_id, type, resource_id, is_read, created_at, updated_at, event_count`
 	row := s.db.QueryRow(query, notification.UserID, notification.Type, notification.FromUserID, notification.ResourceID, notification.IsRead)
 
 	return ScanRowIntoNotification(row)
 }
 
+// NotifyPostActivity tells the user about activity on a post. While they
+// haven't read it, further activity is batched into the same notification,
+// which is counted, moved back to the top and shows the latest sender.
+// Activity from users they blocked or muted is left out, so it can't hide
+// the rest of the batch.
+func (s *Store) NotifyPostActivity(userID int, fromUserID int, postID int) error {
+	query := `
+	INSERT INTO notifications AS n (user_id, type, from_user_id, resource_id, is_read)
+	SELECT $1, 'post', $2, $3, false
+	WHERE ` + moderation.NotHiddenSQL("$2", "$1") + `
+	ON CONFLICT (user_id, resource_id) WHERE type = 'post' AND NOT is_read
+	DO UPDATE SET from_user_id = EXCLUDED.from_user_id, event_count = n.event_count + 1, updated_at = NOW()
+	`
+	if _, err := s.db.Exec(query, userID, fromUserID, postID); err != nil {
+		return fmt.Errorf("error notifying post activity: %w", err)
+	}
+
+	return nil
+}
+
+// NotifyFollow tells the user about a new follower. Following again after
+// an unfollow doesn't add another notification while the first is unread.
+func (s *Store) NotifyFollow(userID int, followerID int) error {
+	query := `
+	INSERT INTO notifications (user_id, type, from_user_id, resource_id, is_read)
+	VALUES ($1, 'follow', $2, $2, false)
+	ON CONFLICT (user_id, resource_id) WHERE type = 'follow' AND NOT is_read
+	DO NOTHING
+	`
+	if _, err := s.db.Exec(query, userID, followerID); err != nil {
+		return fmt.Errorf("error notifying follow: %w", err)
+	}
+
+	return nil
+}
+
 func (s *Store) ReadNotification(notificationID int, userID int) (*types.Notification, error) {
-	query := `UPDATE notifications SET is_read = true WHERE id = $1 AND user_id = $2 RETURNING id, user_id, type, resource_id, is_read, created_at, updated_at`
+	query := `UPDATE notifications SET is_read = true WHERE id = $1 AND user_id = $2 RETURNING id, user_id, type, resource_id, is_read, created_at, updated_at, event_count`
 	row := s.db.QueryRow(query, notificationID, userID)
 
 	notification, err := ScanRowIntoNotification(row)
@@ -77,35 +116,55 @@ type MinimalUser struct {
 	ID          int    `json:"id"`
 	Name        string `json:"name"`
 	Surname     string `json:"surname"`
-	Email       string `json:"email"`
 	UserPicture string `json:"user_picture"`
 }
 
 type NotificationResponse struct {
-	ID          int         `json:"id"`
-	IsRead      bool        `json:"isRead"`
-	CreatedAt   time.Time   `json:"createdAt"`
-	FromUser    MinimalUser `json:"fromUser"`
+	ID        int        `json:"id"`
+	Type      types.Type `json:"type"`
+	IsRead    bool       `json:"isRead"`
+	CreatedAt time.Time  `json:"createdAt"`
+	// UpdatedAt is when a batched notification last got a new event, and
+	// what the notifications are sorted by.
+	UpdatedAt time.Time   `json:"updatedAt"`
+	Count     int         `json:"count"`
+	FromUser  MinimalUser `json:"fromUser"`
+	// Post is set on the post notifications.
+	Post        *NotificationPost `json:"post,omitempty"`
 	Transaction struct {
 		Amount float64 `json:"amount"`
+		// Post is the post the donation was made from, if any.
+		Post *NotificationPost `json:"post,omitempty"`
 	} `json:"transaction"`
 }
 
-func (s *Store) GetNotificationsByUserID(userID int) ([]NotificationResponse, error) {
+type NotificationPost struct {
+	ID          int    `json:"id"`
+	Description string `json:"description"`
+}
+
+func (s *Store) GetNotificationsByUserID(userID int, page utils.PageRequest) (*utils.Page[NotificationResponse], error) {
 	query := `
         SELECT 
-            n.id, n.user_id, n.type, n.resource_id, n.is_read, n.created_at, n.updated_at,
-            u.id as from_user_id, u.name, u.surname, u.email,
+            n.id, n.user_id, n.type, n.resource_id, n.is_read, n.created_at, n.updated_at, n.event_count,
+            u.id as from_user_id, u.name, u.surname,
             pp.path as user_picture,
-            t.id as transaction_id, t.amount, t.created_at as transaction_created_at
+            t.id as transaction_id, t.amount, t.created_at as transaction_created_at,
+            tp.id as post_id, tp.description as post_description,
+            np.id as notification_post_id, np.description as notification_post_description
         FROM notifications n
         LEFT JOIN users u ON n.from_user_id = u.id
         LEFT JOIN profile_pictures pp ON u.id = pp.user_id
-        LEFT JOIN transactions t ON n.resource_id = t.id
-        WHERE n.user_id = $1 
-        ORDER BY n.created_at DESC`
-
-	rows, err := s.db.Query(query, userID)
+        LEFT JOIN transactions t ON n.type = 'payment' AND n.resource_id = t.id
+        LEFT JOIN posts tp ON t.post_id = tp.id
+        LEFT JOIN posts np ON n.type = 'post' AND n.resource_id = np.id
+        WHERE n.user_id = $1
+          AND ` + moderation.NotHiddenSQL("n.from_user_id", "$1") + `
+          AND ($2::timestamp IS NULL OR (n.updated_at, n.id) < ($2, $3))
+        ORDER BY n.updated_at DESC, n.id DESC
+        LIMIT $4`
+
+	rows, err := s.db.Query(query, userID, page.AfterTime(), page.AfterID(), page.Limit+1)
 	if err != nil {
 		return nil, err
 	}
@@ -119,6 +178,10 @@ func (s *Store) GetNotificationsByUserID(userID int) ([]NotificationResponse, er
 		var transactionID sql.NullString
 		var transactionAmount sql.NullFloat64
 		var transactionCreatedAt sql.NullTime
+		var postID sql.NullInt64
+		var postDescription sql.NullString
+		var notificationPostID sql.NullInt64
+		var notificationPostDescription sql.NullString
 
 		err := rows.Scan(
 			&notification.ID,
@@ -128,22 +191,28 @@ func (s *Store) GetNotificationsByUserID(userID int) ([]NotificationResponse, er
 			&notification.IsRead,
 			&notification.CreatedAt,
 			&notification.UpdatedAt,
+			&detail.Count,
 			&detail.FromUser.ID,
 			&detail.FromUser.Name,
 			&detail.FromUser.Surname,
-			&detail.FromUser.Email,
 			&userPicture,
 			&transactionID,
 			&transactionAmount,
 			&transactionCreatedAt,
+			&postID,
+			&postDescription,
+			&notificationPostID,
+			&notificationPostDescription,
 		)
 		if err != nil {
 			return nil, err
 		}
 
 		detail.ID = notification.ID
+		detail.Type = notification.Type
 		detail.IsRead = notification.IsRead
 		detail.CreatedAt = notification.CreatedAt
+		detail.UpdatedAt = notification.UpdatedAt
 
 		if userPicture.Valid {
 			detail.FromUser.UserPicture = userPicture.String
@@ -153,8 +222,23 @@ func (s *Store) GetNotificationsByUserID(userID int) ([]NotificationResponse, er
 			detail.Transaction.Amount = transactionAmount.Float64
 		}
 
+		if postID.Valid {
+			detail.Transaction.Post = &NotificationPost{ID: int(postID.Int64), Description: postDescription.String}
+		}
+
+		if notificationPostID.Valid {
+			detail.Post = &NotificationPost{ID: int(notificationPostID.Int64), Description: notificationPostDescription.String}
+		}
+
 		results = append(results, detail)
 	}
 
-	return results, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return utils.NewPage(results, page.Limit, func(n NotificationResponse) utils.Cursor {
+		updatedAt := n.UpdatedAt
+		return utils.Cursor{Time: &updatedAt, ID: n.ID}
+	}), nil
 }
@@ -8,19 +8,23 @@ import (
 	"github.com/alissoncorsair/appsolidario-backend/config"
 	"github.com/alissoncorsair/appsolidario-backend/payment"
 	"github.com/alissoncorsair/appsolidario-backend/service/auth"
+	"github.com/alissoncorsair/appsolidario-backend/service/session"
 	"github.com/alissoncorsair/appsolidario-backend/service/user"
+	"github.com/alissoncorsair/appsolidario-backend/types"
 	"github.com/alissoncorsair/appsolidario-backend/utils"
 )
 
 type Handler struct {
 	paymentStore *Store
 	userStore    *user.Store
+	sessionStore *session.Store
 }
 
-func NewHandler(paymentStore *Store, userStore *user.Store) *Handler {
+func NewHandler(paymentStore *Store, userStore *user.Store, sessionStore *session.Store) *Handler {
 	return &Handler{
 		paymentStore: paymentStore,
 		userStore:    userStore,
+		sessionStore: sessionStore,
 	}
 }
 
@@ -37,23 +41,26 @@ func (h *Handler) HandleGeneratePix(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, ok := auth.GetUserIDFromContext(r.Context())
+	user, ok := auth.GetUserFromContext(r.Context())
 	if !ok {
 		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
 		return
 	}
 
-	user, err := h.userStore.GetUserByID(userID)
+	payee, err := h.userStore.GetUserByID(payload.ReceiverID)
 
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get user: %w", err))
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get payee: %w", err))
 		return
 	}
 
-	payee, err := h.userStore.GetUserByID(payload.ReceiverID)
+	if payee == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("payee not found"))
+		return
+	}
 
-	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get payee: %w", err))
+	if payee.RoleID != types.RolePayee {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("this user cannot receive donations"))
 		return
 	}
 
@@ -62,6 +69,54 @@ func (h *Handler) HandleGeneratePix(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	blocked, err := h.userStore.IsBlocked(payee.ID, user.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if blocked {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("você não pode fazer doações para este usuário"))
+		return
+	}
+
+	if payload.CampaignID != nil {
+		campaignMessage, err := h.paymentStore.CheckCampaign(*payload.CampaignID, payee.ID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to check campaign: %w", err))
+			return
+		}
+
+		if campaignMessage != "" {
+			utils.WriteError(w, http.StatusUnprocessableEntity, fmt.Errorf("%s", campaignMessage))
+			return
+		}
+	}
+
+	if payload.PostID != nil {
+		postMessage, err := h.paymentStore.CheckPost(*payload.PostID, payee.ID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to check post: %w", err))
+			return
+		}
+
+		if postMessage != "" {
+			utils.WriteError(w, http.StatusUnprocessableEntity, fmt.Errorf("%s", postMessage))
+			return
+		}
+	}
+
+	capMessage, err := h.paymentStore.CheckUnverifiedPayeeCaps(payee, payload.Amount)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to check donation caps: %w", err))
+		return
+	}
+
+	if capMessage != "" {
+		utils.WriteError(w, http.StatusUnprocessableEntity, fmt.Errorf("%s", capMessage))
+		return
+	}
+
 	info, err := h.paymentStore.CreatePayment(payload, *user)
 
 	if err != nil {
@@ -120,7 +175,7 @@ func (h *Handler) HandleMercadoPagoWebhook(w http.ResponseWriter, r *http.Reques
 }
 
 func (h *Handler) RegisterRoutes(router *http.ServeMux) {
-	router.HandleFunc("POST /pay", auth.WithJWTAuth(h.HandleGeneratePix, h.userStore))
-	router.HandleFunc("GET /pay/status/{payment_id}", auth.WithJWTAuth(h.HandleGetPaymentStatus, h.userStore))
+	router.HandleFunc("POST /pay", auth.WithJWTAuth(h.HandleGeneratePix, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /pay/status/{payment_id}", auth.WithJWTAuth(h.HandleGetPaymentStatus, h.userStore, h.sessionStore))
 	router.HandleFunc("POST /webhook/mpago", h.HandleMercadoPagoWebhook)
 }
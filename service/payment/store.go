@@ -3,11 +3,18 @@ package payment
 import (
 	"database/sql"
 	"fmt"
+	"log"
 	"strconv"
+	"time"
 
+	"github.com/alissoncorsair/appsolidario-backend/config"
 	"github.com/alissoncorsair/appsolidario-backend/payment"
+	"github.com/alissoncorsair/appsolidario-backend/service/campaign"
+	"github.com/alissoncorsair/appsolidario-backend/service/gamification"
+	"github.com/alissoncorsair/appsolidario-backend/service/leaderboard"
 	"github.com/alissoncorsair/appsolidario-backend/service/mailer"
 	"github.com/alissoncorsair/appsolidario-backend/service/notification"
+	"github.com/alissoncorsair/appsolidario-backend/service/post"
 	"github.com/alissoncorsair/appsolidario-backend/service/transactions"
 	"github.com/alissoncorsair/appsolidario-backend/service/user"
 	"github.com/alissoncorsair/appsolidario-backend/types"
@@ -20,9 +27,13 @@ type Store struct {
 	notificationStore *notification.Store
 	gateway           payment.MercadoPago
 	mailer            mailer.Mailer
+	gamification      *gamification.Engine
+	leaderboardStore  *leaderboard.Store
+	campaignStore     *campaign.Store
+	postStore         *post.Store
 }
 
-func NewStore(db *sql.DB, gateway payment.MercadoPago, transactionsStore *transactions.Store, userStore *user.Store, notificationsStore *notification.Store, mailer mailer.Mailer) *Store {
+func NewStore(db *sql.DB, gateway payment.MercadoPago, transactionsStore *transactions.Store, userStore *user.Store, notificationsStore *notification.Store, mailer mailer.Mailer, gamification *gamification.Engine, leaderboardStore *leaderboard.Store, campaignStore *campaign.Store, postStore *post.Store) *Store {
 	return &Store{
 		db:                db,
 		transactionsStore: transactionsStore,
@@ -30,6 +41,10 @@ func NewStore(db *sql.DB, gateway payment.MercadoPago, transactionsStore *transa
 		userStore:         userStore,
 		gateway:           gateway,
 		mailer:            mailer,
+		gamification:      gamification,
+		leaderboardStore:  leaderboardStore,
+		campaignStore:     campaignStore,
+		postStore:         postStore,
 	}
 }
 
@@ -64,7 +79,7 @@ func (s *Store) CreatePayment(paymentInfo payment.PaymentInfo, user types.User)
 		}, nil
 	}
 
-	_, err = s.transactionsStore.CreateTransaction(stringId, user.ID, paymentInfo.ReceiverID, paymentInfo.Amount, "Payment")
+	_, err = s.transactionsStore.CreateTransaction(stringId, user.ID, paymentInfo.ReceiverID, paymentInfo.Amount, "Payment", paymentInfo.CampaignID, paymentInfo.PostID)
 
 	if err != nil {
 		return nil, err
@@ -103,8 +118,7 @@ func (s *Store) GetPaymentStatus(paymentID string) (*PaymentStatusResponse, erro
 		}
 
 		status = types.StatusDone
-		_, err = s.transactionsStore.UpdateTransactionStatusAndAmount(strconv.Itoa(paymentInfo.ID), types.StatusDone, paymentInfo.TransactionAmount)
-		if err != nil {
+		if err := s.completeTransaction(strconv.Itoa(paymentInfo.ID), paymentInfo.TransactionAmount); err != nil {
 			return nil, err
 		}
 	}
@@ -149,33 +163,143 @@ func (s *Store) ProcessWebhookEvent(event payment.MercadoPagoWebhookEvent) error
 				return fmt.Errorf("transaction not found")
 			}
 
-			_, err = s.transactionsStore.UpdateTransactionStatusAndAmount(paymentID, types.StatusDone, paymentInfo.TransactionAmount)
-
-			if err != nil {
+			if err := s.completeTransaction(paymentID, paymentInfo.TransactionAmount); err != nil {
 				return err
 			}
+		}
+	default:
+		return fmt.Errorf("unhandled event type: %s", event.Type)
+	}
 
-			notification := &types.Notification{
-				UserID:     transaction.PayeeID,
-				FromUserID: transaction.PayerID,
-				Type:       types.TypePayment,
-				ResourceID: transaction.ID,
-				IsRead:     false,
-			}
+	return nil
+}
+
+// CheckCampaign returns the message to show the payer when the donation can't
+// go to the campaign, or an empty string.
+func (s *Store) CheckCampaign(campaignID int, payeeID int) (string, error) {
+	c, err := s.campaignStore.GetCampaignByID(campaignID)
+	if err != nil {
+		return "", err
+	}
 
-			_, _ = s.notificationStore.CreateNotification(notification)
+	if c == nil || c.UserID != payeeID {
+		return "campanha não encontrada", nil
+	}
 
-			payer, err := s.userStore.GetUserByID(transaction.PayerID)
-			if err == nil && payer != nil {
-				err = s.mailer.SendPaymentThanksEmail(payer, paymentInfo.TransactionAmount)
-				if err != nil {
-					fmt.Printf("failed to send payment thanks email: %v", err)
-				}
-			}
+	if c.Status != types.CampaignOpen || !c.Deadline.After(time.Now()) {
+		return "esta campanha já foi encerrada", nil
+	}
+
+	return "", nil
+}
+
+// CheckPost returns the message to show the payer when the donation can't be
+// linked to the post, which must be one of the payee's, or an empty string.
+func (s *Store) CheckPost(postID int, payeeID int) (string, error) {
+	authorID, err := s.postStore.GetPostAuthorID(postID)
+	if err != nil {
+		return "", err
+	}
+
+	if authorID != payeeID {
+		return "publicação não encontrada", nil
+	}
+
+	return "", nil
+}
+
+// CheckUnverifiedPayeeCaps enforces the donation caps of payees that are not
+// verified yet: a maximum per donation and a maximum received over the last
+// 30 days, pending donations included, in reais, a zero cap being disabled.
+// It returns the message to show the payer when the donation goes over a
+// cap, or an empty string.
+func (s *Store) CheckUnverifiedPayeeCaps(payee *types.User, amount float64) (string, error) {
+	if payee.IsVerified {
+		return "", nil
+	}
+
+	maxDonation := float64(config.Envs.UnverifiedPayeeMaxDonation)
+	if maxDonation > 0 && amount > maxDonation {
+		return fmt.Sprintf("este beneficiário ainda não foi verificado e só pode receber doações de até R$ %.2f", maxDonation), nil
+	}
+
+	monthlyLimit := float64(config.Envs.UnverifiedPayeeMonthlyLimit)
+	if monthlyLimit <= 0 {
+		return "", nil
+	}
+
+	// Pix that were generated but not paid yet count too, or any number of
+	// them could be paid afterwards
+	received, err := s.transactionsStore.GetReceivedAmountSince(payee.ID, time.Now().AddDate(0, 0, -30), time.Now().Add(-payment.PixExpiration))
+	if err != nil {
+		return "", err
+	}
+
+	if received+amount > monthlyLimit {
+		return fmt.Sprintf("este beneficiário ainda não foi verificado e atingiu o limite de R$ %.2f em doações nos últimos 30 dias", monthlyLimit), nil
+	}
+
+	return "", nil
+}
+
+// completeTransaction marks the transaction as done and, the first time only,
+// notifies the payee, thanks the payer, updates their points and leaderboard
+// stats and the progress of the campaign the donation went to. Both the
+// webhook and the status polling can get here for the same payment. The
+// campaign progress is derived from the confirmed donations, so the campaign
+// worker makes up for an update lost here.
+func (s *Store) completeTransaction(externalID string, amount float64) error {
+	transaction, err := s.transactionsStore.CompleteTransaction(externalID, amount)
+
+	if err != nil {
+		return err
+	}
+
+	if transaction == nil {
+		return nil
+	}
+
+	notification := &types.Notification{
+		UserID:     transaction.PayeeID,
+		FromUserID: transaction.PayerID,
+		Type:       types.TypePayment,
+		ResourceID: transaction.ID,
+		IsRead:     false,
+	}
+
+	_, _ = s.notificationStore.CreateNotification(notification)
+
+	if err := s.leaderboardStore.RecordDonation(transaction.PayerID, transaction.Amount); err != nil {
+		log.Printf("failed to record donation on the leaderboard: %v", err)
+	}
+
+	if transaction.CampaignID != nil {
+		s.recordCampaignDonation(transaction)
+	}
+
+	s.gamification.DonationConfirmed(transaction)
+
+	payer, err := s.userStore.GetUserByID(transaction.PayerID)
+	if err == nil && payer != nil {
+		err = s.mailer.SendPaymentThanksEmail(payer, amount)
+		if err != nil {
+			fmt.Printf("failed to send payment thanks email: %v", err)
 		}
-	default:
-		return fmt.Errorf("unhandled event type: %s", event.Type)
 	}
 
 	return nil
 }
+
+// recordCampaignDonation updates the progress of the donation's campaign and,
+// when the donation reached the goal, lets every donor of the campaign know.
+func (s *Store) recordCampaignDonation(transaction *types.Transaction) {
+	completed, err := s.campaignStore.RecordDonation(*transaction.CampaignID)
+	if err != nil {
+		log.Printf("failed to record donation %d on campaign %d: %v", transaction.ID, *transaction.CampaignID, err)
+		return
+	}
+
+	if completed != nil {
+		campaign.NotifyCompletion(s.campaignStore, s.notificationStore, completed)
+	}
+}
@@ -4,12 +4,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 
+	"github.com/alissoncorsair/appsolidario-backend/geo"
 	"github.com/alissoncorsair/appsolidario-backend/service/auth"
+	"github.com/alissoncorsair/appsolidario-backend/service/gamification"
+	"github.com/alissoncorsair/appsolidario-backend/service/notification"
+	"github.com/alissoncorsair/appsolidario-backend/service/session"
 	"github.com/alissoncorsair/appsolidario-backend/service/user"
 	"github.com/alissoncorsair/appsolidario-backend/storage"
 	"github.com/alissoncorsair/appsolidario-backend/types"
@@ -18,16 +25,22 @@ import (
 )
 
 type Handler struct {
-	postStore *Store
-	userStore *user.Store
-	storage   *storage.R2Storage
+	postStore         *Store
+	userStore         *user.Store
+	sessionStore      *session.Store
+	notificationStore *notification.Store
+	storage           *storage.R2Storage
+	gamification      *gamification.Engine
 }
 
-func NewHandler(postStore *Store, userStore *user.Store, storage *storage.R2Storage) *Handler {
+func NewHandler(postStore *Store, userStore *user.Store, sessionStore *session.Store, notificationStore *notification.Store, storage *storage.R2Storage, gamification *gamification.Engine) *Handler {
 	return &Handler{
-		postStore: postStore,
-		userStore: userStore,
-		storage:   storage,
+		postStore:         postStore,
+		userStore:         userStore,
+		sessionStore:      sessionStore,
+		notificationStore: notificationStore,
+		storage:           storage,
+		gamification:      gamification,
 	}
 }
 
@@ -90,6 +103,8 @@ func (h *Handler) HandleCreatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.gamification.PostCreated(userID)
+
 	userPicture, err := h.userStore.GetUserProfilePicture(userID)
 
 	if err != nil {
@@ -124,7 +139,7 @@ func (h *Handler) HandleDeletePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	post, err := h.postStore.GetPostByID(postID)
+	post, err := h.postStore.GetPostByID(userID, postID)
 	if err != nil {
 		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("post not found"))
 		return
@@ -182,6 +197,28 @@ func (h *Handler) HandleCreateComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	authorID, err := h.postStore.GetPostAuthorID(postID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if authorID == 0 {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("post not found"))
+		return
+	}
+
+	blocked, err := h.userStore.IsBlocked(authorID, userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if blocked {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("você não pode comentar nesta publicação"))
+		return
+	}
+
 	comment := &types.Comment{
 		PostID:     postID,
 		UserID:     userID,
@@ -242,6 +279,490 @@ func (h *Handler) HandleDeleteComment(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Comment deleted successfully"})
 }
 
+// HandleUpdatePost edits a post from a multipart form where every field is
+// optional: "description" replaces the text, "remove_photos" lists the
+// filenames of the photos to drop, "photo_order" lists the filenames of the
+// remaining photos in the order they should be shown and the "photos" files
+// are added after them. The replaced version is kept as a revision.
+func (h *Handler) HandleUpdatePost(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid post ID"))
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	if err := r.ParseMultipartForm(10 << 20); err != nil { // 10 MB max
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("failed to parse form: %w", err))
+		return
+	}
+
+	post, err := h.postStore.GetPostByID(userID, postID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("post not found"))
+		return
+	}
+
+	if post.UserID != userID {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("you don't have permission to edit this post"))
+		return
+	}
+
+	description := post.Description
+	if values, ok := r.MultipartForm.Value["description"]; ok {
+		description = strings.TrimSpace(values[0])
+
+		if description == "" {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("description cannot be empty"))
+			return
+		}
+	}
+
+	photos, err := editPhotos(post.Photos, r.MultipartForm.Value["remove_photos"], r.MultipartForm.Value["photo_order"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	files := r.MultipartForm.File["photos"]
+	if description == post.Description && slices.Equal(photos, post.Photos) && len(files) == 0 {
+		utils.WriteJSON(w, http.StatusOK, post)
+		return
+	}
+
+	var uploaded []string
+	for _, fileHeader := range files {
+		file, err := fileHeader.Open()
+		if err != nil {
+			h.deletePhotos(r, uploaded)
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to open file: %w", err))
+			return
+		}
+
+		_, filename, err := h.storage.UploadFile(r.Context(), file, fileHeader.Filename)
+		file.Close()
+		if err != nil {
+			h.deletePhotos(r, uploaded)
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to upload file: %w", err))
+			return
+		}
+
+		uploaded = append(uploaded, filename)
+	}
+
+	err = h.postStore.UpdatePost(postID, description, append(photos, uploaded...))
+	if err != nil {
+		h.deletePhotos(r, uploaded)
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to update post: %w", err))
+		return
+	}
+
+	updated, err := h.postStore.GetPostByID(userID, postID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get post: %w", err))
+		return
+	}
+
+	userPicture, err := h.userStore.GetUserProfilePicture(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get user profile picture: %w", err))
+		return
+	}
+
+	if userPicture != nil {
+		updated.UserPicture = userPicture.Path
+	}
+
+	utils.WriteJSON(w, http.StatusOK, updated)
+}
+
+// editPhotos returns the photos of a post after dropping the removed ones and
+// applying the new order, which must list every remaining photo once.
+func editPhotos(current []string, remove []string, order []string) ([]string, error) {
+	removed := make(map[string]bool, len(remove))
+	for _, filename := range remove {
+		if !slices.Contains(current, filename) {
+			return nil, fmt.Errorf("photo %s is not in this post", filename)
+		}
+		removed[filename] = true
+	}
+
+	kept := []string{}
+	for _, filename := range current {
+		if !removed[filename] {
+			kept = append(kept, filename)
+		}
+	}
+
+	if len(order) == 0 {
+		return kept, nil
+	}
+
+	sorted := slices.Clone(order)
+	slices.Sort(sorted)
+	expected := slices.Clone(kept)
+	slices.Sort(expected)
+
+	if !slices.Equal(sorted, expected) {
+		return nil, fmt.Errorf("photo_order must list each remaining photo once")
+	}
+
+	return order, nil
+}
+
+func (h *Handler) deletePhotos(r *http.Request, filenames []string) {
+	for _, filename := range filenames {
+		if err := h.storage.DeleteFile(r.Context(), filename); err != nil {
+			log.Printf("failed to delete post photo %s: %v", filename, err)
+		}
+	}
+}
+
+// HandleGetPostRevisions lists the previous versions of a post to its author
+// and to admins.
+func (h *Handler) HandleGetPostRevisions(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid post ID"))
+		return
+	}
+
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	authorID, err := h.postStore.GetPostAuthorID(postID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if authorID == 0 {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("post not found"))
+		return
+	}
+
+	if authorID != user.ID && user.RoleID != types.RoleAdmin {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("you don't have permission to see the revisions of this post"))
+		return
+	}
+
+	page, err := utils.ParsePageRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	revisions, err := h.postStore.GetPostRevisions(postID, page)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, revisions)
+}
+
+// HandleUpdateComment replaces the content of a comment, keeping the
+// previous one as a revision.
+func (h *Handler) HandleUpdateComment(w http.ResponseWriter, r *http.Request) {
+	commentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid comment ID"))
+		return
+	}
+
+	var payload types.UpdateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request payload"))
+		return
+	}
+
+	payload.Content = strings.TrimSpace(payload.Content)
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	comment, err := h.postStore.GetCommentByID(commentID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("comment not found"))
+		return
+	}
+
+	if comment.UserID != userID {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("you don't have permission to edit this comment"))
+		return
+	}
+
+	if payload.Content == comment.Content {
+		utils.WriteJSON(w, http.StatusOK, comment)
+		return
+	}
+
+	updated, err := h.postStore.UpdateComment(commentID, payload.Content)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to update comment: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, updated)
+}
+
+// HandleGetCommentRevisions lists the previous versions of a comment to its
+// author and to admins.
+func (h *Handler) HandleGetCommentRevisions(w http.ResponseWriter, r *http.Request) {
+	commentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid comment ID"))
+		return
+	}
+
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	comment, err := h.postStore.GetCommentByID(commentID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("comment not found"))
+		return
+	}
+
+	if comment.UserID != user.ID && user.RoleID != types.RoleAdmin {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("you don't have permission to see the revisions of this comment"))
+		return
+	}
+
+	page, err := utils.ParsePageRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	revisions, err := h.postStore.GetCommentRevisions(commentID, page)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, revisions)
+}
+
+// HandleReactToPost sets the viewer's reaction to a post, replacing the one
+// they had, and returns the updated reactions. The author is only notified
+// of new reactions.
+func (h *Handler) HandleReactToPost(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid post ID"))
+		return
+	}
+
+	var payload types.ReactRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request payload"))
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	authorID, err := h.postStore.GetPostAuthorID(postID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if authorID == 0 {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("post not found"))
+		return
+	}
+
+	blocked, err := h.userStore.IsBlocked(authorID, userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if blocked {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("você não pode reagir a esta publicação"))
+		return
+	}
+
+	created, err := h.postStore.ReactToPost(userID, postID, payload.Kind)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if created && authorID != userID {
+		if err := h.notificationStore.NotifyPostActivity(authorID, userID, postID); err != nil {
+			log.Printf("failed to create reaction notification: %v", err)
+		}
+	}
+
+	reactions, err := h.postStore.GetPostReactions(userID, postID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, reactions)
+}
+
+func (h *Handler) HandleRemovePostReaction(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid post ID"))
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	removed, err := h.postStore.RemovePostReaction(userID, postID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !removed {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("você não reagiu a esta publicação"))
+		return
+	}
+
+	reactions, err := h.postStore.GetPostReactions(userID, postID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, reactions)
+}
+
+// HandleReactToComment sets the viewer's reaction to a comment, replacing
+// the one they had, and returns the updated reactions. The author is only
+// notified of new reactions.
+func (h *Handler) HandleReactToComment(w http.ResponseWriter, r *http.Request) {
+	commentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid comment ID"))
+		return
+	}
+
+	var payload types.ReactRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request payload"))
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	comment, err := h.postStore.GetCommentByID(commentID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("comment not found"))
+		return
+	}
+
+	blocked, err := h.userStore.IsBlocked(comment.UserID, userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if blocked {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("você não pode reagir a este comentário"))
+		return
+	}
+
+	created, err := h.postStore.ReactToComment(userID, commentID, payload.Kind)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if created && comment.UserID != userID {
+		if err := h.notificationStore.NotifyPostActivity(comment.UserID, userID, comment.PostID); err != nil {
+			log.Printf("failed to create reaction notification: %v", err)
+		}
+	}
+
+	reactions, err := h.postStore.GetCommentReactions(userID, commentID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, reactions)
+}
+
+func (h *Handler) HandleRemoveCommentReaction(w http.ResponseWriter, r *http.Request) {
+	commentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid comment ID"))
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	removed, err := h.postStore.RemoveCommentReaction(userID, commentID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !removed {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("você não reagiu a este comentário"))
+		return
+	}
+
+	reactions, err := h.postStore.GetCommentReactions(userID, commentID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, reactions)
+}
+
 func (h *Handler) HandleUploadPhoto(w http.ResponseWriter, r *http.Request) {
 	// Parse the multipart form data
 	err := r.ParseMultipartForm(10 << 20) // 10 MB max
@@ -281,12 +802,18 @@ func (h *Handler) HandleUploadPhoto(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) HandleGetPhoto(w http.ResponseWriter, r *http.Request) {
-	filename := filepath.Base(r.URL.Path)
+	filename := r.PathValue("filename")
 	if filename == "" {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid filename"))
 		return
 	}
 
+	// documents and exports live in the same bucket and have their own routes
+	if !storage.IsPublic(filename) {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("file not found"))
+		return
+	}
+
 	file, err := h.storage.GetFile(r.Context(), filename)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get file: %w", err))
@@ -311,12 +838,25 @@ func (h *Handler) HandleGetPostByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	post, err := h.postStore.GetPostByID(postID)
+	viewerID, _ := auth.GetUserIDFromContext(r.Context())
+
+	post, err := h.postStore.GetPostByID(viewerID, postID)
 	if err != nil {
 		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("post not found"))
 		return
 	}
 
+	blocked, err := h.userStore.IsBlocked(viewerID, post.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if blocked {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("post not found"))
+		return
+	}
+
 	post.UserPicture = ""
 	userPicture, err := h.userStore.GetUserProfilePicture(post.UserID)
 
@@ -352,7 +892,13 @@ func (h *Handler) HandleGetOwnPosts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	posts, err := h.postStore.GetPostsByUserID(userID)
+	page, err := utils.ParsePageRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	posts, err := h.postStore.GetPostsByUserID(userID, userID, page)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get posts: %w", err))
 		return
@@ -374,7 +920,15 @@ func (h *Handler) HandleGetPostsByUserId(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	posts, err := h.postStore.GetPostsByUserID(userID)
+	page, err := utils.ParsePageRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	viewerID, _ := auth.GetUserIDFromContext(r.Context())
+
+	posts, err := h.postStore.GetPostsByUserID(viewerID, userID, page)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get posts: %w", err))
 		return
@@ -400,8 +954,82 @@ func (h *Handler) HandleGetPostsByCity(w http.ResponseWriter, r *http.Request) {
 		city = user.City
 	}
 
-	posts, err := h.postStore.GetPostsByCity(city)
+	page, err := utils.ParsePageRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	posts, err := h.postStore.GetPostsByCity(userID, geo.CanonicalCityName(city), page)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get posts: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, posts)
+}
+
+func (h *Handler) HandleGetComments(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(r.PathValue("post_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid post ID"))
+		return
+	}
+
+	page, err := utils.ParsePageRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	viewerID, _ := auth.GetUserIDFromContext(r.Context())
+
+	comments, err := h.postStore.GetCommentsByPostID(viewerID, postID, page)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get comments: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, comments)
+}
+
+func (h *Handler) HandleGetFeed(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	page, err := utils.ParsePageRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	posts, err := h.postStore.GetFeed(user.ID, user.City, page)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get feed: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, posts)
+}
+
+func (h *Handler) HandleGetPostsNearby(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not authenticated"))
+		return
+	}
+
+	query, err := utils.ParseNearbyQuery(r, user.Latitude, user.Longitude)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
 
+	posts, err := h.postStore.GetPostsNearby(user.ID, query.Latitude, query.Longitude, query.RadiusKm, query.Page)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get posts: %w", err))
 		return
@@ -410,14 +1038,67 @@ func (h *Handler) HandleGetPostsByCity(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSON(w, http.StatusOK, posts)
 }
 
+func (h *Handler) HandleModeratePost(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid post ID"))
+		return
+	}
+
+	adminID, _ := auth.GetUserIDFromContext(r.Context())
+
+	_, err = h.postStore.GetPostByID(adminID, postID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("post not found"))
+		return
+	}
+
+	err = h.postStore.DeletePost(postID, h.storage)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to delete post: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Post deleted successfully"})
+}
+
+func (h *Handler) HandleModerateComment(w http.ResponseWriter, r *http.Request) {
+	commentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid comment ID"))
+		return
+	}
+
+	err = h.postStore.DeleteComment(commentID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to delete comment: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Comment deleted successfully"})
+}
+
 func (h *Handler) RegisterRoutes(router *http.ServeMux) {
-	router.HandleFunc("POST /posts", auth.WithJWTAuth(h.HandleCreatePost, h.userStore))
-	router.HandleFunc("GET /post/{id}", auth.WithJWTAuth(h.HandleGetPostByID, h.userStore))
-	router.HandleFunc("GET /posts/user/{id}", auth.WithJWTAuth(h.HandleGetPostsByUserId, h.userStore))
-	router.HandleFunc("GET /me/posts", auth.WithJWTAuth(h.HandleGetOwnPosts, h.userStore))
-	router.HandleFunc("DELETE /posts/{id}", auth.WithJWTAuth(h.HandleDeletePost, h.userStore))
-	router.HandleFunc("POST /comments/{post_id}", auth.WithJWTAuth(h.HandleCreateComment, h.userStore))
-	router.HandleFunc("DELETE /comments/{id}", auth.WithJWTAuth(h.HandleDeleteComment, h.userStore))
+	router.HandleFunc("POST /posts", auth.WithJWTAuth(h.HandleCreatePost, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /post/{id}", auth.WithJWTAuth(h.HandleGetPostByID, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /posts/user/{id}", auth.WithJWTAuth(h.HandleGetPostsByUserId, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /me/posts", auth.WithJWTAuth(h.HandleGetOwnPosts, h.userStore, h.sessionStore))
+	router.HandleFunc("DELETE /posts/{id}", auth.WithJWTAuth(h.HandleDeletePost, h.userStore, h.sessionStore))
+	router.HandleFunc("PATCH /posts/{id}", auth.WithJWTAuth(h.HandleUpdatePost, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /post/{id}/revisions", auth.WithJWTAuth(h.HandleGetPostRevisions, h.userStore, h.sessionStore))
+	router.HandleFunc("PUT /posts/{id}/reaction", auth.WithJWTAuth(h.HandleReactToPost, h.userStore, h.sessionStore))
+	router.HandleFunc("DELETE /posts/{id}/reaction", auth.WithJWTAuth(h.HandleRemovePostReaction, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /comments/{post_id}", auth.WithJWTAuth(h.HandleGetComments, h.userStore, h.sessionStore))
+	router.HandleFunc("POST /comments/{post_id}", auth.WithJWTAuth(h.HandleCreateComment, h.userStore, h.sessionStore))
+	router.HandleFunc("DELETE /comments/{id}", auth.WithJWTAuth(h.HandleDeleteComment, h.userStore, h.sessionStore))
+	router.HandleFunc("PATCH /comments/{id}", auth.WithJWTAuth(h.HandleUpdateComment, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /comments/{id}/revisions", auth.WithJWTAuth(h.HandleGetCommentRevisions, h.userStore, h.sessionStore))
+	router.HandleFunc("PUT /comments/{id}/reaction", auth.WithJWTAuth(h.HandleReactToComment, h.userStore, h.sessionStore))
+	router.HandleFunc("DELETE /comments/{id}/reaction", auth.WithJWTAuth(h.HandleRemoveCommentReaction, h.userStore, h.sessionStore))
 	router.HandleFunc("GET /photos/{filename}", h.HandleGetPhoto)
-	router.HandleFunc("GET /posts/city", auth.WithJWTAuth(h.HandleGetPostsByCity, h.userStore))
+	router.HandleFunc("GET /feed", auth.WithJWTAuth(h.HandleGetFeed, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /posts/nearby", auth.WithJWTAuth(h.HandleGetPostsNearby, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /posts/city", auth.WithJWTAuth(h.HandleGetPostsByCity, h.userStore, h.sessionStore))
+	router.HandleFunc("DELETE /admin/posts/{id}", auth.WithJWTAuth(auth.WithRole(h.HandleModeratePost, types.RoleAdmin), h.userStore, h.sessionStore))
+	router.HandleFunc("DELETE /admin/comments/{id}", auth.WithJWTAuth(auth.WithRole(h.HandleModerateComment, types.RoleAdmin), h.userStore, h.sessionStore))
 }
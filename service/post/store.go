@@ -5,8 +5,12 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/alissoncorsair/appsolidario-backend/geo"
+	"github.com/alissoncorsair/appsolidario-backend/service/moderation"
 	"github.com/alissoncorsair/appsolidario-backend/storage"
 	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+	"github.com/lib/pq"
 )
 
 type Store struct {
@@ -40,11 +44,11 @@ func (s *Store) CreatePost(post *types.Post) (*types.Post, error) {
 
 	if len(post.Photos) > 0 {
 		photoQuery := `
-            INSERT INTO post_photos (post_id, filename)
-            VALUES ($1, $2)
+            INSERT INTO post_photos (post_id, filename, position)
+            VALUES ($1, $2, $3)
         `
-		for _, filename := range post.Photos {
-			_, err = tx.Exec(photoQuery, post.ID, filename)
+		for i, filename := range post.Photos {
+			_, err = tx.Exec(photoQuery, post.ID, filename, i)
 			if err != nil {
 				return nil, fmt.Errorf("error adding photo: %w", err)
 			}
@@ -57,6 +61,7 @@ func (s *Store) CreatePost(post *types.Post) (*types.Post, error) {
 	}
 
 	post.Comments = []*types.Comment{}
+	post.Reactions = emptyReactions()
 
 	if len(post.Photos) == 0 {
 		post.Photos = []string{}
@@ -65,16 +70,21 @@ func (s *Store) CreatePost(post *types.Post) (*types.Post, error) {
 	return post, nil
 }
 
-func (s *Store) GetPostByID(id int) (*types.Post, error) {
+func (s *Store) GetPostByID(viewerID int, id int) (*types.Post, error) {
 	query := `
-        SELECT p.id, p.user_id, p.author_name, p.description, p.created_at, p.updated_at
+        SELECT p.id, p.user_id, p.author_name, p.description, p.created_at, p.updated_at, p.edited_at, u.is_verified,
+               d.raised_amount, d.donors
         FROM posts p
+        JOIN users u ON p.user_id = u.id
+        ` + donationsJoinSQL("$2") + `
         WHERE p.id = $1
     `
 	var post types.Post
-	err := s.db.QueryRow(query, id).Scan(
+	var donations types.PostDonations
+	err := s.db.QueryRow(query, id, types.StatusDone).Scan(
 		&post.ID, &post.UserID, &post.AuthorName, &post.Description,
-		&post.CreatedAt, &post.UpdatedAt,
+		&post.CreatedAt, &post.UpdatedAt, &post.EditedAt, &post.AuthorVerified,
+		&donations.RaisedAmount, &donations.Donors,
 	)
 
 	if err != nil {
@@ -84,10 +94,12 @@ func (s *Store) GetPostByID(id int) (*types.Post, error) {
 		return nil, fmt.Errorf("error getting post: %w", err)
 	}
 
+	post.Donations = &donations
+
 	photoQuery := `
         SELECT filename FROM post_photos
         WHERE post_id = $1
-        ORDER BY created_at
+        ORDER BY position, id
     `
 	rows, err := s.db.Query(photoQuery, id)
 	if err != nil {
@@ -103,95 +115,380 @@ func (s *Store) GetPostByID(id int) (*types.Post, error) {
 		post.Photos = append(post.Photos, filename)
 	}
 
-	comments, err := s.GetCommentsByPostID(id)
-
-	if err != nil {
-		return nil, fmt.Errorf("error getting comments: %w", err)
+	if err := s.loadComments(&post, viewerID); err != nil {
+		return nil, err
 	}
 
-	post.Comments = comments
+	if err := s.loadPostReactions(viewerID, []*types.Post{&post}); err != nil {
+		return nil, err
+	}
 
 	return &post, nil
 }
 
-func (s *Store) GetPostsByCity(city string) ([]*types.Post, error) {
+// donationsJoinSQL joins, as d, the sum and the number of donors of the
+// confirmed donations made from the post p. statusParam is the query
+// parameter holding types.StatusDone.
+func donationsJoinSQL(statusParam string) string {
+	return `LEFT JOIN LATERAL (
+            SELECT COALESCE(SUM(t.amount), 0)::float8 AS raised_amount, COUNT(DISTINCT t.payer_id) AS donors
+            FROM transactions t
+            WHERE t.post_id = p.id AND t.status = ` + statusParam + `
+        ) d ON TRUE`
+}
+
+// GetPostAuthorID returns 0 when the post doesn't exist.
+func (s *Store) GetPostAuthorID(id int) (int, error) {
+	var userID int
+	err := s.db.QueryRow("SELECT user_id FROM posts WHERE id = $1", id).Scan(&userID)
+
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("error getting post author: %w", err)
+	}
+
+	return userID, nil
+}
+
+// GetPostsByCity lists the posts of a city, newest first, leaving out the
+// authors the viewer blocked or muted and the ones that hide their city.
+func (s *Store) GetPostsByCity(viewerID int, city string, page utils.PageRequest) (*utils.Page[*types.Post], error) {
 	query := `
         SELECT p.id, p.user_id, p.description, p.author_name, 
-               p.created_at, p.updated_at, pp.path, u.city as user_city
+               p.created_at, p.updated_at, p.edited_at, pp.path,
+               CASE WHEN COALESCE(ps.show_city, TRUE) THEN u.city ELSE '' END as user_city, u.is_verified,
+               d.raised_amount, d.donors
         FROM posts p
         JOIN users u ON p.user_id = u.id
         LEFT JOIN profile_pictures pp ON u.id = pp.user_id
-        WHERE u.city = $1
-        ORDER BY p.created_at DESC
+        LEFT JOIN privacy_settings ps ON u.id = ps.user_id
+        ` + donationsJoinSQL("$6") + `
+        WHERE u.city = $1 AND COALESCE(ps.show_city, TRUE)
+          AND ` + moderation.NotHiddenSQL("p.user_id", "$5") + `
+          AND ($2::timestamp IS NULL OR (p.created_at, p.id) < ($2, $3))
+        ORDER BY p.created_at DESC, p.id DESC
+        LIMIT $4
     `
-	rows, err := s.db.Query(query, city)
+	rows, err := s.db.Query(query, city, page.AfterTime(), page.AfterID(), page.Limit+1, viewerID, types.StatusDone)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get posts: %w", err)
 	}
 	defer rows.Close()
 
-	var posts []*types.Post
+	posts := []*types.Post{}
 	for rows.Next() {
 		var post types.Post
 		var userPicture sql.NullString
+		var donations types.PostDonations
 		if err := rows.Scan(
 			&post.ID,
 			&post.UserID,
 			&post.Description,
 			&post.AuthorName,
 			&post.CreatedAt,
-			&post.UpdatedAt,
+			&post.UpdatedAt, &post.EditedAt,
 			&userPicture,
 			&post.UserCity,
+			&post.AuthorVerified,
+			&donations.RaisedAmount,
+			&donations.Donors,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan post: %w", err)
 		}
 		if userPicture.Valid {
 			post.UserPicture = userPicture.String
 		}
+		post.Donations = &donations
 
-		comments, err := s.GetCommentsByPostID(post.ID)
+		posts = append(posts, &post)
+	}
 
-		if err != nil {
-			return nil, fmt.Errorf("error getting comments: %w", err)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over posts: %w", err)
+	}
+
+	rows.Close()
+
+	return s.newPostsPage(posts, page.Limit, viewerID)
+}
+
+// GetFeed is the home feed of a user: the posts of the users they follow and
+// the ones from their city, newest first, except for the authors they
+// blocked or muted. Authors that hide their city only show up through
+// follows.
+func (s *Store) GetFeed(userID int, city string, page utils.PageRequest) (*utils.Page[*types.Post], error) {
+	query := `
+        SELECT p.id, p.user_id, p.description, p.author_name,
+               p.created_at, p.updated_at, p.edited_at, pp.path,
+               CASE WHEN COALESCE(ps.show_city, TRUE) THEN u.city ELSE '' END as user_city,
+               u.is_verified
+        FROM posts p
+        JOIN users u ON p.user_id = u.id
+        LEFT JOIN profile_pictures pp ON u.id = pp.user_id
+        LEFT JOIN privacy_settings ps ON u.id = ps.user_id
+        WHERE (p.user_id IN (SELECT followee_id FROM follows WHERE follower_id = $1)
+               OR (u.city = $2 AND COALESCE(ps.show_city, TRUE)))
+          AND ` + moderation.NotHiddenSQL("p.user_id", "$1") + `
+          AND ($3::timestamp IS NULL OR (p.created_at, p.id) < ($3, $4))
+        ORDER BY p.created_at DESC, p.id DESC
+        LIMIT $5
+    `
+	rows, err := s.db.Query(query, userID, city, page.AfterTime(), page.AfterID(), page.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed: %w", err)
+	}
+	defer rows.Close()
+
+	posts := []*types.Post{}
+	for rows.Next() {
+		var post types.Post
+		var userPicture sql.NullString
+		if err := rows.Scan(
+			&post.ID,
+			&post.UserID,
+			&post.Description,
+			&post.AuthorName,
+			&post.CreatedAt,
+			&post.UpdatedAt, &post.EditedAt,
+			&userPicture,
+			&post.UserCity,
+			&post.AuthorVerified,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+
+		if userPicture.Valid {
+			post.UserPicture = userPicture.String
 		}
 
-		post.Comments = comments
+		posts = append(posts, &post)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over posts: %w", err)
+	}
+
+	rows.Close()
+
+	return s.newPostsPage(posts, page.Limit, userID)
+}
+
+// GetPostsNearby lists the posts of the users within radiusKm of the given
+// point, closest authors first and newest posts first among them. Authors
+// that hide their city, or that the viewer blocked or muted, are left out.
+func (s *Store) GetPostsNearby(viewerID int, latitude, longitude, radiusKm float64, page utils.PageRequest) (*utils.Page[*types.Post], error) {
+	box := geo.BoundingBox(latitude, longitude, radiusKm)
+	query := `
+        SELECT * FROM (
+            SELECT p.id, p.user_id, p.description, p.author_name,
+                   p.created_at, p.updated_at, p.edited_at, pp.path,
+                   CASE WHEN COALESCE(ps.show_city, TRUE) THEN u.city ELSE '' END as user_city, u.is_verified,
+                   ` + geo.DistanceSQL("u.latitude", "u.longitude", "$1", "$2") + ` AS distance_km
+            FROM posts p
+            JOIN users u ON p.user_id = u.id
+            LEFT JOIN profile_pictures pp ON u.id = pp.user_id
+            LEFT JOIN privacy_settings ps ON u.id = ps.user_id
+            WHERE u.latitude BETWEEN $3 AND $4
+              AND u.longitude BETWEEN $5 AND $6
+              AND COALESCE(ps.show_city, TRUE)
+              AND ` + moderation.NotHiddenSQL("p.user_id", "$12") + `
+        ) nearby
+        WHERE distance_km <= $7
+          AND ($8::float8 IS NULL OR distance_km > $8 OR (distance_km = $8 AND (created_at, id) < ($9, $10)))
+        ORDER BY distance_km, created_at DESC, id DESC
+        LIMIT $11
+    `
+	rows, err := s.db.Query(query, latitude, longitude, box.MinLat, box.MaxLat, box.MinLon, box.MaxLon, radiusKm,
+		page.AfterValue(), page.AfterTime(), page.AfterID(), page.Limit+1, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nearby posts: %w", err)
+	}
+	defer rows.Close()
+
+	posts := []*types.Post{}
+	for rows.Next() {
+		var post types.Post
+		var userPicture sql.NullString
+		var distance float64
+		if err := rows.Scan(
+			&post.ID,
+			&post.UserID,
+			&post.Description,
+			&post.AuthorName,
+			&post.CreatedAt,
+			&post.UpdatedAt, &post.EditedAt,
+			&userPicture,
+			&post.UserCity,
+			&post.AuthorVerified,
+			&distance,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
 
-		if len(post.Comments) == 0 {
-			post.Comments = []*types.Comment{}
+		if userPicture.Valid {
+			post.UserPicture = userPicture.String
 		}
 
-		photos, err := s.GetPhotosByPostID(post.ID)
+		post.DistanceKm = &distance
+		posts = append(posts, &post)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over posts: %w", err)
+	}
+
+	rows.Close()
 
+	return s.newPostsPage(posts, page.Limit, viewerID)
+}
+
+// newPostsPage pages posts sorted by creation date (and, for the nearby ones,
+// distance) and loads the photos and first comments the viewer can see of
+// the ones kept.
+func (s *Store) newPostsPage(posts []*types.Post, limit int, viewerID int) (*utils.Page[*types.Post], error) {
+	page := utils.NewPage(posts, limit, func(post *types.Post) utils.Cursor {
+		createdAt := post.CreatedAt
+		return utils.Cursor{Time: &createdAt, Value: post.DistanceKm, ID: post.ID}
+	})
+
+	for _, post := range page.Items {
+		if err := s.loadComments(post, viewerID); err != nil {
+			return nil, err
+		}
+
+		photos, err := s.GetPhotosByPostID(post.ID)
 		if err != nil {
 			return nil, fmt.Errorf("error getting photos: %w", err)
 		}
 
+		post.Photos = []string{}
 		for _, photo := range photos {
 			post.Photos = append(post.Photos, photo.Filename)
 		}
+	}
+
+	if err := s.loadPostReactions(viewerID, page.Items); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// loadComments embeds the first page of comments in the post. The rest is
+// fetched with GetCommentsByPostID from CommentsNextCursor.
+func (s *Store) loadComments(post *types.Post, viewerID int) error {
+	comments, err := s.GetCommentsByPostID(viewerID, post.ID, utils.PageRequest{Limit: utils.DefaultPageLimit})
+	if err != nil {
+		return fmt.Errorf("error getting comments: %w", err)
+	}
+
+	post.Comments = comments.Items
+	post.CommentsNextCursor = comments.NextCursor
+
+	return nil
+}
+
+// SearchPosts runs a full-text search over the post descriptions. The filters
+// apply to the authors, and the ones the viewer blocked or muted are left
+// out. Results come by rank and then id, starting after
+// (afterRank, afterID) when afterRank is set.
+func (s *Store) SearchPosts(viewerID int, text string, filters types.SearchFilters, afterRank *float64, afterID int, limit int) ([]*types.SearchResult, error) {
+	query := `
+        SELECT id, user_id, description, author_name, created_at, updated_at, edited_at, path, user_city, is_verified, rank,
+               ts_headline('portuguese_unaccent', description, websearch_to_tsquery('portuguese_unaccent', $1), '` + types.SearchHeadlineOptions + `')
+        FROM (
+            SELECT p.id, p.user_id, p.description, p.author_name, p.created_at, p.updated_at, p.edited_at, pp.path,
+                   CASE WHEN COALESCE(ps.show_city, TRUE) THEN u.city ELSE '' END AS user_city, u.is_verified,
+                   ts_rank(p.search_vector, websearch_to_tsquery('portuguese_unaccent', $1))::float8 AS rank
+            FROM posts p
+            JOIN users u ON p.user_id = u.id
+            LEFT JOIN profile_pictures pp ON u.id = pp.user_id
+            LEFT JOIN privacy_settings ps ON u.id = ps.user_id
+            WHERE p.search_vector @@ websearch_to_tsquery('portuguese_unaccent', $1)
+              AND ($2 = '' OR (u.city = $2 AND COALESCE(ps.show_city, TRUE)))
+              AND ($3 = '' OR (u.state = $3 AND COALESCE(ps.show_state, TRUE)))
+              AND ($4 = 0 OR u.role_id = $4)
+              AND ` + moderation.NotHiddenSQL("p.user_id", "$8") + `
+        ) ranked
+        WHERE $5::float8 IS NULL OR rank < $5 OR (rank = $5 AND id > $6)
+        ORDER BY rank DESC, id
+        LIMIT $7
+    `
+	rows, err := s.db.Query(query, text, filters.City, filters.State, filters.RoleID, afterRank, afterID, limit, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search posts: %w", err)
+	}
+	defer rows.Close()
+
+	results := []*types.SearchResult{}
+	for rows.Next() {
+		var post types.Post
+		var userPicture sql.NullString
+		result := &types.SearchResult{Type: types.SearchResultPost}
+		if err := rows.Scan(
+			&post.ID,
+			&post.UserID,
+			&post.Description,
+			&post.AuthorName,
+			&post.CreatedAt,
+			&post.UpdatedAt, &post.EditedAt,
+			&userPicture,
+			&post.UserCity,
+			&post.AuthorVerified,
+			&result.Rank,
+			&result.Snippet,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
 
-		if len(post.Photos) == 0 {
-			post.Photos = []string{}
+		if userPicture.Valid {
+			post.UserPicture = userPicture.String
 		}
 
-		posts = append(posts, &post)
+		post.Comments = []*types.Comment{}
+		result.Post = &post
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over posts: %w", err)
+	}
+
+	rows.Close()
+
+	for _, result := range results {
+		photos, err := s.GetPhotosByPostID(result.Post.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error getting photos: %w", err)
+		}
+
+		result.Post.Photos = []string{}
+		for _, photo := range photos {
+			result.Post.Photos = append(result.Post.Photos, photo.Filename)
+		}
+	}
+
+	posts := make([]*types.Post, 0, len(results))
+	for _, result := range results {
+		posts = append(posts, result.Post)
 	}
 
-	if len(posts) == 0 {
-		return []*types.Post{}, nil
+	if err := s.loadPostReactions(viewerID, posts); err != nil {
+		return nil, err
 	}
 
-	return posts, nil
+	return results, nil
 }
 
 func (s *Store) GetPhotosByPostID(postID int) ([]types.PostPhoto, error) {
 	query := `
-        SELECT id, post_id, filename, created_at
+        SELECT id, post_id, filename, position, created_at
         FROM post_photos
         WHERE post_id = $1
-        ORDER BY id ASC
+        ORDER BY position, id
     `
 	rows, err := s.db.Query(query, postID)
 	if err != nil {
@@ -202,7 +499,7 @@ func (s *Store) GetPhotosByPostID(postID int) ([]types.PostPhoto, error) {
 	var photos []types.PostPhoto
 	for rows.Next() {
 		var photo types.PostPhoto
-		if err := rows.Scan(&photo.ID, &photo.PostID, &photo.Filename, &photo.CreatedAt); err != nil {
+		if err := rows.Scan(&photo.ID, &photo.PostID, &photo.Filename, &photo.Position, &photo.CreatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan photo: %w", err)
 		}
 		photos = append(photos, photo)
@@ -219,18 +516,25 @@ func (s *Store) GetPhotosByPostID(postID int) ([]types.PostPhoto, error) {
 	return photos, nil
 }
 
-func (s *Store) GetPostsByUserID(id int) ([]*types.Post, error) {
+// GetPostsByUserID lists the posts of a user, newest first. It is empty
+// when the viewer blocked the user.
+func (s *Store) GetPostsByUserID(viewerID int, id int, page utils.PageRequest) (*utils.Page[*types.Post], error) {
 
 	query := `
-	SELECT p.id, p.user_id, p.author_name, p.description, p.created_at, p.updated_at, pp.path, u.city as user_city
+	SELECT p.id, p.user_id, p.author_name, p.description, p.created_at, p.updated_at, p.edited_at, pp.path,
+	       CASE WHEN COALESCE(ps.show_city, TRUE) THEN u.city ELSE '' END as user_city, u.is_verified
 	FROM posts p
 	JOIN users u ON p.user_id = u.id
 	LEFT JOIN profile_pictures pp ON u.id = pp.user_id
+	LEFT JOIN privacy_settings ps ON u.id = ps.user_id
 	WHERE p.user_id = $1
-	ORDER BY p.created_at DESC
+	  AND ` + moderation.NotBlockedSQL("p.user_id", "$5") + `
+	  AND ($2::timestamp IS NULL OR (p.created_at, p.id) < ($2, $3))
+	ORDER BY p.created_at DESC, p.id DESC
+	LIMIT $4
 `
 
-	rows, err := s.db.Query(query, id)
+	rows, err := s.db.Query(query, id, page.AfterTime(), page.AfterID(), page.Limit+1, viewerID)
 
 	if err != nil {
 		return nil, fmt.Errorf("error getting posts: %w", err)
@@ -238,13 +542,13 @@ func (s *Store) GetPostsByUserID(id int) ([]*types.Post, error) {
 
 	defer rows.Close()
 
-	var posts []*types.Post
+	posts := []*types.Post{}
 	for rows.Next() {
 		var post types.Post
 		var userPicture sql.NullString
 		if err := rows.Scan(
 			&post.ID, &post.UserID, &post.AuthorName, &post.Description,
-			&post.CreatedAt, &post.UpdatedAt, &userPicture, &post.UserCity,
+			&post.CreatedAt, &post.UpdatedAt, &post.EditedAt, &userPicture, &post.UserCity, &post.AuthorVerified,
 		); err != nil {
 			return nil, fmt.Errorf("error scanning post: %w", err)
 		}
@@ -253,32 +557,16 @@ func (s *Store) GetPostsByUserID(id int) ([]*types.Post, error) {
 			post.UserPicture = userPicture.String
 		}
 
-		comments, err := s.GetCommentsByPostID(post.ID)
-
-		if err != nil {
-			return nil, fmt.Errorf("error getting comments: %w", err)
-		}
-
-		post.Comments = comments
-
-		if len(post.Comments) == 0 {
-			post.Comments = []*types.Comment{}
-		}
-
-		photos, err := s.GetPhotosByPostID(post.ID)
-
-		if err != nil {
-			return nil, fmt.Errorf("error getting photos: %w", err)
-		}
-
-		for _, photo := range photos {
-			post.Photos = append(post.Photos, photo.Filename)
-		}
-
 		posts = append(posts, &post)
 	}
 
-	return posts, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over posts: %w", err)
+	}
+
+	rows.Close()
+
+	return s.newPostsPage(posts, page.Limit, viewerID)
 }
 
 func (s *Store) CreateComment(comment *types.Comment) (*types.Comment, error) {
@@ -294,19 +582,21 @@ func (s *Store) CreateComment(comment *types.Comment) (*types.Comment, error) {
 		return nil, fmt.Errorf("error creating comment: %w", err)
 	}
 
+	comment.Reactions = emptyReactions()
+
 	return comment, nil
 }
 
 func (s *Store) GetCommentByID(commentID int) (*types.Comment, error) {
 	query := `
-		SELECT id, post_id, user_id, author_name, content, created_at, updated_at
+		SELECT id, post_id, user_id, author_name, content, created_at, updated_at, edited_at
 		FROM comments
 		WHERE id = $1
 	`
 	var comment types.Comment
 	err := s.db.QueryRow(query, commentID).Scan(
 		&comment.ID, &comment.PostID, &comment.UserID, &comment.AuthorName, &comment.Content,
-		&comment.CreatedAt, &comment.UpdatedAt,
+		&comment.CreatedAt, &comment.UpdatedAt, &comment.EditedAt,
 	)
 
 	if err != nil {
@@ -337,16 +627,21 @@ func (s *Store) DeleteComment(commentID int) error {
 	return nil
 }
 
-func (s *Store) GetCommentsByPostID(postID int) ([]*types.Comment, error) {
+// GetCommentsByPostID lists the comments of a post, oldest first, leaving
+// out the ones by users the viewer blocked.
+func (s *Store) GetCommentsByPostID(viewerID int, postID int, page utils.PageRequest) (*utils.Page[*types.Comment], error) {
 	query := `
-	SELECT c.id, c.post_id, c.user_id, c.author_name, c.content, c.created_at, c.updated_at, pp.path
+	SELECT c.id, c.post_id, c.user_id, c.author_name, c.content, c.created_at, c.updated_at, c.edited_at, pp.path
 	FROM comments c
 	JOIN users u ON c.user_id = u.id
 	LEFT JOIN profile_pictures pp ON u.id = pp.user_id
 	WHERE c.post_id = $1
-	ORDER BY c.created_at ASC
+	  AND ` + moderation.NotBlockedSQL("c.user_id", "$5") + `
+	  AND ($2::timestamp IS NULL OR (c.created_at, c.id) > ($2, $3))
+	ORDER BY c.created_at ASC, c.id ASC
+	LIMIT $4
 `
-	rows, err := s.db.Query(query, postID)
+	rows, err := s.db.Query(query, postID, page.AfterTime(), page.AfterID(), page.Limit+1, viewerID)
 	if err != nil {
 		return nil, fmt.Errorf("error getting comments: %w", err)
 	}
@@ -358,7 +653,7 @@ func (s *Store) GetCommentsByPostID(postID int) ([]*types.Comment, error) {
 		var userPicture sql.NullString
 		if err := rows.Scan(
 			&comment.ID, &comment.PostID, &comment.UserID, &comment.AuthorName, &comment.Content,
-			&comment.CreatedAt, &comment.UpdatedAt, &userPicture,
+			&comment.CreatedAt, &comment.UpdatedAt, &comment.EditedAt, &userPicture,
 		); err != nil {
 			return nil, fmt.Errorf("error scanning comment: %w", err)
 		}
@@ -370,7 +665,22 @@ func (s *Store) GetCommentsByPostID(postID int) ([]*types.Comment, error) {
 		comments = append(comments, &comment)
 	}
 
-	return comments, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over comments: %w", err)
+	}
+
+	rows.Close()
+
+	result := utils.NewPage(comments, page.Limit, func(comment *types.Comment) utils.Cursor {
+		createdAt := comment.CreatedAt
+		return utils.Cursor{Time: &createdAt, ID: comment.ID}
+	})
+
+	if err := s.loadCommentReactions(viewerID, result.Items); err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
 func (s *Store) DeletePost(postID int, storageClient *storage.R2Storage) error {
@@ -381,7 +691,12 @@ func (s *Store) DeletePost(postID int, storageClient *storage.R2Storage) error {
 	defer tx.Rollback()
 
 	var filenames []string
-	photoQuery := "SELECT filename FROM post_photos WHERE post_id = $1"
+	// photos removed by an edit are kept for the revisions until now
+	photoQuery := `
+	SELECT filename FROM post_photos WHERE post_id = $1
+	UNION
+	SELECT unnest(photos) FROM post_revisions WHERE post_id = $1
+	`
 	rows, err := tx.Query(photoQuery, postID)
 	if err != nil {
 		return fmt.Errorf("error fetching post photos: %w", err)
@@ -403,6 +718,11 @@ func (s *Store) DeletePost(postID int, storageClient *storage.R2Storage) error {
 		}
 	}
 
+	_, err = tx.Exec("DELETE FROM notifications WHERE type = $1 AND resource_id = $2", types.TypePost, postID)
+	if err != nil {
+		return fmt.Errorf("error deleting notifications: %w", err)
+	}
+
 	// Delete associated comments
 	_, err = tx.Exec("DELETE FROM comments WHERE post_id = $1", postID)
 	if err != nil {
@@ -437,3 +757,356 @@ func (s *Store) DeletePost(postID int, storageClient *storage.R2Storage) error {
 
 	return nil
 }
+
+// UpdatePost replaces the description and the photos of a post, photos
+// being the filenames in the order they are shown, and keeps the previous
+// version as a revision. Removed photos stay in the storage while a revision
+// still shows them, and are deleted with the post.
+func (s *Store) UpdatePost(postID int, description string, photos []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// concurrent edits would otherwise save the same version twice
+	var id int
+	err = tx.QueryRow("SELECT id FROM posts WHERE id = $1 FOR UPDATE", postID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("post not found")
+	}
+
+	if err != nil {
+		return fmt.Errorf("error locking post: %w", err)
+	}
+
+	query := `
+	INSERT INTO post_revisions (post_id, description, photos)
+	SELECT p.id, p.description, ARRAY(SELECT ph.filename FROM post_photos ph WHERE ph.post_id = p.id ORDER BY ph.position, ph.id)
+	FROM posts p
+	WHERE p.id = $1
+	`
+	if _, err := tx.Exec(query, postID); err != nil {
+		return fmt.Errorf("error saving post revision: %w", err)
+	}
+
+	if _, err := tx.Exec("UPDATE posts SET description = $1, edited_at = NOW(), updated_at = NOW() WHERE id = $2", description, postID); err != nil {
+		return fmt.Errorf("error updating post: %w", err)
+	}
+
+	// a nil slice would be sent as NULL and keep every photo
+	if photos == nil {
+		photos = []string{}
+	}
+
+	if _, err := tx.Exec("DELETE FROM post_photos WHERE post_id = $1 AND filename <> ALL($2)", postID, pq.Array(photos)); err != nil {
+		return fmt.Errorf("error removing post photos: %w", err)
+	}
+
+	for i, filename := range photos {
+		result, err := tx.Exec("UPDATE post_photos SET position = $1 WHERE post_id = $2 AND filename = $3", i, postID, filename)
+		if err != nil {
+			return fmt.Errorf("error reordering post photos: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error getting rows affected: %w", err)
+		}
+
+		if rowsAffected > 0 {
+			continue
+		}
+
+		if _, err := tx.Exec("INSERT INTO post_photos (post_id, filename, position) VALUES ($1, $2, $3)", postID, filename, i); err != nil {
+			return fmt.Errorf("error adding photo: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetPostRevisions lists the previous versions of a post, newest first.
+func (s *Store) GetPostRevisions(postID int, page utils.PageRequest) (*utils.Page[*types.PostRevision], error) {
+	query := `
+	SELECT id, post_id, description, photos, created_at
+	FROM post_revisions
+	WHERE post_id = $1
+	  AND ($2::timestamp IS NULL OR (created_at, id) < ($2, $3))
+	ORDER BY created_at DESC, id DESC
+	LIMIT $4
+	`
+	rows, err := s.db.Query(query, postID, page.AfterTime(), page.AfterID(), page.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error getting post revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []*types.PostRevision{}
+	for rows.Next() {
+		var r types.PostRevision
+		if err := rows.Scan(&r.ID, &r.PostID, &r.Description, pq.Array(&r.Photos), &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning post revision: %w", err)
+		}
+		revisions = append(revisions, &r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return utils.NewPage(revisions, page.Limit, func(r *types.PostRevision) utils.Cursor {
+		return utils.Cursor{Time: &r.CreatedAt, ID: r.ID}
+	}), nil
+}
+
+// UpdateComment replaces the content of a comment, keeping the previous one
+// as a revision.
+func (s *Store) UpdateComment(commentID int, content string) (*types.Comment, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT INTO comment_revisions (comment_id, content) SELECT id, content FROM comments WHERE id = $1", commentID); err != nil {
+		return nil, fmt.Errorf("error saving comment revision: %w", err)
+	}
+
+	query := `
+	UPDATE comments
+	SET content = $1, edited_at = NOW(), updated_at = NOW()
+	WHERE id = $2
+	RETURNING id, post_id, user_id, author_name, content, created_at, updated_at, edited_at
+	`
+	var comment types.Comment
+	err = tx.QueryRow(query, content, commentID).Scan(
+		&comment.ID, &comment.PostID, &comment.UserID, &comment.AuthorName, &comment.Content,
+		&comment.CreatedAt, &comment.UpdatedAt, &comment.EditedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("comment not found")
+		}
+		return nil, fmt.Errorf("error updating comment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	if err := s.loadCommentReactions(comment.UserID, []*types.Comment{&comment}); err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+// GetCommentRevisions lists the previous versions of a comment, newest
+// first.
+func (s *Store) GetCommentRevisions(commentID int, page utils.PageRequest) (*utils.Page[*types.CommentRevision], error) {
+	query := `
+	SELECT id, comment_id, content, created_at
+	FROM comment_revisions
+	WHERE comment_id = $1
+	  AND ($2::timestamp IS NULL OR (created_at, id) < ($2, $3))
+	ORDER BY created_at DESC, id DESC
+	LIMIT $4
+	`
+	rows, err := s.db.Query(query, commentID, page.AfterTime(), page.AfterID(), page.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error getting comment revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []*types.CommentRevision{}
+	for rows.Next() {
+		var r types.CommentRevision
+		if err := rows.Scan(&r.ID, &r.CommentID, &r.Content, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning comment revision: %w", err)
+		}
+		revisions = append(revisions, &r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return utils.NewPage(revisions, page.Limit, func(r *types.CommentRevision) utils.Cursor {
+		return utils.Cursor{Time: &r.CreatedAt, ID: r.ID}
+	}), nil
+}
+
+func emptyReactions() *types.Reactions {
+	return &types.Reactions{Counts: map[types.ReactionKind]int{}}
+}
+
+// getReactions sums up the reactions to the posts or comments with the given
+// ids, column being either post_id or comment_id.
+func (s *Store) getReactions(column string, viewerID int, ids []int) (map[int]*types.Reactions, error) {
+	reactions := make(map[int]*types.Reactions, len(ids))
+	targets := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		reactions[id] = emptyReactions()
+		targets = append(targets, int64(id))
+	}
+
+	if len(targets) == 0 {
+		return reactions, nil
+	}
+
+	query := `
+	SELECT ` + column + `, kind, COUNT(*), BOOL_OR(user_id = $2)
+	FROM reactions
+	WHERE ` + column + ` = ANY($1)
+	GROUP BY ` + column + `, kind
+	`
+	rows, err := s.db.Query(query, pq.Array(targets), viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting reactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, count int
+		var kind types.ReactionKind
+		var own bool
+		if err := rows.Scan(&id, &kind, &count, &own); err != nil {
+			return nil, fmt.Errorf("error scanning reactions: %w", err)
+		}
+
+		r := reactions[id]
+		r.Counts[kind] = count
+		r.Total += count
+		if own {
+			r.ViewerReaction = &kind
+		}
+	}
+
+	return reactions, rows.Err()
+}
+
+func (s *Store) loadPostReactions(viewerID int, posts []*types.Post) error {
+	ids := make([]int, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+
+	reactions, err := s.getReactions("post_id", viewerID, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, post := range posts {
+		post.Reactions = reactions[post.ID]
+	}
+
+	return nil
+}
+
+func (s *Store) loadCommentReactions(viewerID int, comments []*types.Comment) error {
+	ids := make([]int, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+
+	reactions, err := s.getReactions("comment_id", viewerID, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, comment := range comments {
+		comment.Reactions = reactions[comment.ID]
+	}
+
+	return nil
+}
+
+// ReactToPost sets the user's reaction to a post, replacing the previous
+// one. It returns true when the user hadn't reacted to the post yet.
+func (s *Store) ReactToPost(userID int, postID int, kind types.ReactionKind) (bool, error) {
+	query := `
+	INSERT INTO reactions (user_id, post_id, kind)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (post_id, user_id) WHERE post_id IS NOT NULL
+	DO UPDATE SET kind = EXCLUDED.kind, updated_at = NOW()
+	RETURNING xmax = 0
+	`
+	return s.react(query, userID, postID, kind)
+}
+
+// ReactToComment sets the user's reaction to a comment, replacing the
+// previous one. It returns true when the user hadn't reacted to the comment
+// yet.
+func (s *Store) ReactToComment(userID int, commentID int, kind types.ReactionKind) (bool, error) {
+	query := `
+	INSERT INTO reactions (user_id, comment_id, kind)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (comment_id, user_id) WHERE comment_id IS NOT NULL
+	DO UPDATE SET kind = EXCLUDED.kind, updated_at = NOW()
+	RETURNING xmax = 0
+	`
+	return s.react(query, userID, commentID, kind)
+}
+
+// react runs an upsert returning xmax = 0, which only holds for a row that
+// was inserted rather than updated.
+func (s *Store) react(query string, userID int, targetID int, kind types.ReactionKind) (bool, error) {
+	var created bool
+	if err := s.db.QueryRow(query, userID, targetID, kind).Scan(&created); err != nil {
+		return false, fmt.Errorf("error saving reaction: %w", err)
+	}
+
+	return created, nil
+}
+
+// RemovePostReaction returns false when the user hadn't reacted to the post.
+func (s *Store) RemovePostReaction(userID int, postID int) (bool, error) {
+	return s.removeReaction("DELETE FROM reactions WHERE user_id = $1 AND post_id = $2", userID, postID)
+}
+
+// RemoveCommentReaction returns false when the user hadn't reacted to the
+// comment.
+func (s *Store) RemoveCommentReaction(userID int, commentID int) (bool, error) {
+	return s.removeReaction("DELETE FROM reactions WHERE user_id = $1 AND comment_id = $2", userID, commentID)
+}
+
+func (s *Store) removeReaction(query string, userID int, targetID int) (bool, error) {
+	result, err := s.db.Exec(query, userID, targetID)
+	if err != nil {
+		return false, fmt.Errorf("error removing reaction: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetPostReactions sums up the reactions to a post as seen by the viewer.
+func (s *Store) GetPostReactions(viewerID int, postID int) (*types.Reactions, error) {
+	reactions, err := s.getReactions("post_id", viewerID, []int{postID})
+	if err != nil {
+		return nil, err
+	}
+
+	return reactions[postID], nil
+}
+
+// GetCommentReactions sums up the reactions to a comment as seen by the
+// viewer.
+func (s *Store) GetCommentReactions(viewerID int, commentID int) (*types.Reactions, error) {
+	reactions, err := s.getReactions("comment_id", viewerID, []int{commentID})
+	if err != nil {
+		return nil, err
+	}
+
+	return reactions[commentID], nil
+}
@@ -0,0 +1,54 @@
+package search
+
+import (
+	"math"
+
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+)
+
+// Results are ordered by rank, then type and then id, so the cursor carries
+// the three: the rank as its value and the type as its kind.
+func cursorOf(result *types.SearchResult) utils.Cursor {
+	rank := result.Rank
+	return utils.Cursor{Value: &rank, Kind: string(result.Type), ID: resultID(result)}
+}
+
+// after translates the cursor into the (rank, id) position the store of the
+// given result type should resume from. On a rank tie, types sorting before
+// the cursor's were already returned and types sorting after it were not.
+func after(c *utils.Cursor, resultType types.SearchResultType) (*float64, int) {
+	if c == nil || c.Value == nil {
+		return nil, 0
+	}
+
+	switch kind := types.SearchResultType(c.Kind); {
+	case resultType == kind:
+		return c.Value, c.ID
+	case resultType > kind:
+		return c.Value, 0
+	default:
+		return c.Value, math.MaxInt32
+	}
+}
+
+// less is the order of the results across types.
+func less(a, b *types.SearchResult) bool {
+	if a.Rank != b.Rank {
+		return a.Rank > b.Rank
+	}
+
+	if a.Type != b.Type {
+		return a.Type < b.Type
+	}
+
+	return resultID(a) < resultID(b)
+}
+
+func resultID(r *types.SearchResult) int {
+	if r.Type == types.SearchResultPost {
+		return r.Post.ID
+	}
+
+	return r.User.ID
+}
@@ -0,0 +1,134 @@
+package search
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alissoncorsair/appsolidario-backend/geo"
+	"github.com/alissoncorsair/appsolidario-backend/service/auth"
+	"github.com/alissoncorsair/appsolidario-backend/service/post"
+	"github.com/alissoncorsair/appsolidario-backend/service/session"
+	"github.com/alissoncorsair/appsolidario-backend/service/user"
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+)
+
+type Handler struct {
+	userStore    *user.Store
+	postStore    *post.Store
+	sessionStore *session.Store
+}
+
+func NewHandler(userStore *user.Store, postStore *post.Store, sessionStore *session.Store) *Handler {
+	return &Handler{
+		userStore:    userStore,
+		postStore:    postStore,
+		sessionStore: sessionStore,
+	}
+}
+
+// HandleSearch searches users and posts together, ranked by relevance. The
+// optional type parameter ("user" or "post") restricts the search to one of
+// them.
+func (h *Handler) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	viewerID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	values := r.URL.Query()
+
+	text := strings.TrimSpace(values.Get("q"))
+	if len([]rune(text)) < 2 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("a busca precisa ter ao menos 2 caracteres"))
+		return
+	}
+
+	filters, err := parseFilters(values.Get("city"), values.Get("state"), values.Get("role"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resultType := types.SearchResultType(values.Get("type"))
+	if resultType != "" && resultType != types.SearchResultUser && resultType != types.SearchResultPost {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("type deve ser user ou post"))
+		return
+	}
+
+	page, err := utils.ParsePageRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// each store returns one more than the page, so we know if there's a
+	// next one after merging
+	var results []*types.SearchResult
+
+	if resultType == "" || resultType == types.SearchResultUser {
+		afterRank, afterID := after(page.After, types.SearchResultUser)
+
+		users, err := h.userStore.SearchUsers(viewerID, text, filters, afterRank, afterID, page.Limit+1)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to search users: %w", err))
+			return
+		}
+
+		results = append(results, users...)
+	}
+
+	if resultType == "" || resultType == types.SearchResultPost {
+		afterRank, afterID := after(page.After, types.SearchResultPost)
+
+		posts, err := h.postStore.SearchPosts(viewerID, text, filters, afterRank, afterID, page.Limit+1)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to search posts: %w", err))
+			return
+		}
+
+		results = append(results, posts...)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return less(results[i], results[j])
+	})
+
+	utils.WriteJSON(w, http.StatusOK, utils.NewPage(results, page.Limit, cursorOf))
+}
+
+func parseFilters(city string, state string, role string) (types.SearchFilters, error) {
+	var filters types.SearchFilters
+
+	if strings.TrimSpace(city) != "" {
+		filters.City = geo.CanonicalCityName(city)
+	}
+
+	if strings.TrimSpace(state) != "" {
+		s, ok := geo.FindState(state)
+		if !ok {
+			return filters, geo.ErrUnknownState
+		}
+
+		filters.State = s.UF
+	}
+
+	if role != "" {
+		roleID, err := strconv.Atoi(role)
+		if err != nil || (types.UserRole(roleID) != types.RolePayee && types.UserRole(roleID) != types.RolePayer) {
+			return filters, fmt.Errorf("role inválida")
+		}
+
+		filters.RoleID = types.UserRole(roleID)
+	}
+
+	return filters, nil
+}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /search", auth.WithJWTAuth(h.HandleSearch, h.userStore, h.sessionStore))
+}
@@ -0,0 +1,168 @@
+package session
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/alissoncorsair/appsolidario-backend/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+func ScanRowIntoSession(row *sql.Row) (*types.Session, error) {
+	var s types.Session
+	var deviceName, userAgent, ipAddress sql.NullString
+	err := row.Scan(&s.ID, &s.UserID, &deviceName, &userAgent, &ipAddress, &s.CreatedAt, &s.LastUsedAt, &s.RevokedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.DeviceName = deviceName.String
+	s.UserAgent = userAgent.String
+	s.IPAddress = ipAddress.String
+
+	return &s, nil
+}
+
+func (s *Store) CreateSession(session *types.Session) (*types.Session, error) {
+	query := `
+		INSERT INTO sessions (user_id, device_name, user_agent, ip_address)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, device_name, user_agent, ip_address, created_at, last_used_at, revoked_at
+	`
+	row := s.db.QueryRow(query, session.UserID, session.DeviceName, session.UserAgent, session.IPAddress)
+
+	return ScanRowIntoSession(row)
+}
+
+func (s *Store) GetSessionByID(id int) (*types.Session, error) {
+	query := `
+		SELECT id, user_id, device_name, user_agent, ip_address, created_at, last_used_at, revoked_at
+		FROM sessions
+		WHERE id = $1
+	`
+	row := s.db.QueryRow(query, id)
+
+	return ScanRowIntoSession(row)
+}
+
+// GetSessionsByUserID returns the sessions that are still active.
+func (s *Store) GetSessionsByUserID(userID int) ([]*types.Session, error) {
+	query := `
+		SELECT id, user_id, device_name, user_agent, ip_address, created_at, last_used_at, revoked_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY last_used_at DESC
+	`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []*types.Session{}
+	for rows.Next() {
+		var session types.Session
+		var deviceName, userAgent, ipAddress sql.NullString
+
+		err := rows.Scan(&session.ID, &session.UserID, &deviceName, &userAgent, &ipAddress, &session.CreatedAt, &session.LastUsedAt, &session.RevokedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning session: %w", err)
+		}
+
+		session.DeviceName = deviceName.String
+		session.UserAgent = userAgent.String
+		session.IPAddress = ipAddress.String
+
+		sessions = append(sessions, &session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (s *Store) IsSessionActive(id int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM sessions WHERE id = $1 AND revoked_at IS NULL)`
+
+	var active bool
+	if err := s.db.QueryRow(query, id).Scan(&active); err != nil {
+		return false, fmt.Errorf("error checking session: %w", err)
+	}
+
+	return active, nil
+}
+
+func (s *Store) TouchSession(id int) error {
+	_, err := s.db.Exec("UPDATE sessions SET last_used_at = NOW() WHERE id = $1", id)
+
+	if err != nil {
+		return fmt.Errorf("error updating session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeSession revokes a session owned by the user and reports whether an
+// active session was found.
+func (s *Store) RevokeSession(id int, userID int) (bool, error) {
+	query := `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+	result, err := s.db.Exec(query, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("error revoking session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (s *Store) RevokeUserSessions(userID int) error {
+	query := `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`
+	_, err := s.db.Exec(query, userID)
+	if err != nil {
+		return fmt.Errorf("error revoking sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) RevokeOtherUserSessions(userID int, keepSessionID int) error {
+	query := `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL
+	`
+	_, err := s.db.Exec(query, userID, keepSessionID)
+	if err != nil {
+		return fmt.Errorf("error revoking sessions: %w", err)
+	}
+
+	return nil
+}
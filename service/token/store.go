@@ -1,7 +1,11 @@
 package token
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"fmt"
 
 	"github.com/alissoncorsair/appsolidario-backend/types"
 )
@@ -16,35 +20,130 @@ func NewStore(db *sql.DB) *Store {
 	}
 }
 
+// Generate returns a random opaque token suitable for links sent by email.
+func Generate() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating token: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// Hash returns the value persisted in the tokens table, so a database leak
+// does not hand out usable tokens.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func (s *Store) CreateToken(token *types.Token) (*types.Token, error) {
 	query := `
-		INSERT INTO tokens (user_id, token, token_type)
-		VALUES ($1, $2, $3)
-		RETURNING id
+		INSERT INTO tokens (user_id, token, type, expires_at, session_id, email)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
 	`
-	var id int
-	err := s.db.QueryRow(query, token.UserID, token.Token, token.Type).Scan(&id)
+	err := s.db.QueryRow(query, token.UserID, token.Token, token.Type, token.ExpiresAt, token.SessionID, token.Email).Scan(&token.ID, &token.CreatedAt)
 
 	if err != nil {
 		return nil, err
 	}
 
-	token.ID = id
 	return token, nil
 }
 
 func (s *Store) GetToken(token string) (*types.Token, error) {
 	query := `
-		SELECT * FROM tokens WHERE token = $1
+		SELECT id, user_id, token, type, created_at, expires_at, revoked_at, session_id, email
+		FROM tokens
+		WHERE token = $1
 	`
 
 	row := s.db.QueryRow(query, token)
 	return ScanRowIntoToken(row)
 }
 
+// RevokeToken marks the token as revoked and reports whether it was still
+// active. Refresh rotation relies on this being atomic: only one request can
+// win the revocation of a given token.
+func (s *Store) RevokeToken(id int) (bool, error) {
+	query := `
+		UPDATE tokens
+		SET revoked_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL
+	`
+	result, err := s.db.Exec(query, id)
+
+	if err != nil {
+		return false, fmt.Errorf("error revoking token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (s *Store) RevokeUserTokens(userID int, tokenType types.TokenType) error {
+	query := `
+		UPDATE tokens
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND type = $2 AND revoked_at IS NULL
+	`
+	_, err := s.db.Exec(query, userID, tokenType)
+
+	if err != nil {
+		return fmt.Errorf("error revoking tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteToken(id int) error {
+	_, err := s.db.Exec("DELETE FROM tokens WHERE id = $1", id)
+
+	if err != nil {
+		return fmt.Errorf("error deleting token: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeToken deletes a single use token and reports whether it was still
+// there, so only one of two concurrent requests can use it.
+func (s *Store) ConsumeToken(id int) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM tokens WHERE id = $1", id)
+
+	if err != nil {
+		return false, fmt.Errorf("error consuming token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (s *Store) DeleteUserTokens(userID int, tokenType types.TokenType) error {
+	_, err := s.db.Exec("DELETE FROM tokens WHERE user_id = $1 AND type = $2", userID, tokenType)
+
+	if err != nil {
+		return fmt.Errorf("error deleting tokens: %w", err)
+	}
+
+	return nil
+}
+
 func ScanRowIntoToken(row *sql.Row) (*types.Token, error) {
 	var t types.Token
-	err := row.Scan(&t.ID, &t.UserID, &t.Token, &t.Type, &t.CreatedAt, &t.ExpiresAt)
+	err := row.Scan(&t.ID, &t.UserID, &t.Token, &t.Type, &t.CreatedAt, &t.ExpiresAt, &t.RevokedAt, &t.SessionID, &t.Email)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -2,6 +2,8 @@ package transactions
 
 import (
 	"database/sql"
+	"fmt"
+	"time"
 
 	"github.com/alissoncorsair/appsolidario-backend/types"
 )
@@ -18,7 +20,7 @@ func NewStore(db *sql.DB) *Store {
 
 func ScanRowIntoTransaction(row *sql.Row) (*types.Transaction, error) {
 	var t types.Transaction
-	err := row.Scan(&t.ID, &t.ExternalID, &t.PayerID, &t.PayeeID, &t.Amount, &t.Status, &t.Description, &t.CreatedAt, &t.UpdatedAt)
+	err := row.Scan(&t.ID, &t.ExternalID, &t.PayerID, &t.PayeeID, &t.Amount, &t.Status, &t.Description, &t.CreatedAt, &t.UpdatedAt, &t.CampaignID, &t.PostID)
 
 	if err != nil {
 		return nil, err
@@ -27,7 +29,10 @@ func ScanRowIntoTransaction(row *sql.Row) (*types.Transaction, error) {
 	return &t, nil
 }
 
-func (s *Store) CreateTransaction(externalId string, payerID, payeeID int, amount float64, description string) (*types.Transaction, error) {
+// CreateTransaction records a pending donation, made to one of the payee's
+// campaigns when campaignID is set and from one of their posts when postID
+// is.
+func (s *Store) CreateTransaction(externalId string, payerID, payeeID int, amount float64, description string, campaignID *int, postID *int) (*types.Transaction, error) {
 	transaction, err := s.GetTransactionByExternalID(externalId)
 
 	if err != nil && err != sql.ErrNoRows {
@@ -38,22 +43,56 @@ func (s *Store) CreateTransaction(externalId string, payerID, payeeID int, amoun
 		return nil, nil
 	}
 
-	query := `INSERT INTO transactions (external_id, payer_id, payee_id, amount, status, description) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, external_id, payer_id, payee_id, amount, status, description, created_at, updated_at`
-	row := s.db.QueryRow(query, externalId, payerID, payeeID, amount, types.StatusPending, description)
+	query := `INSERT INTO transactions (external_id, payer_id, payee_id, amount, status, description, campaign_id, post_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, external_id, payer_id, payee_id, amount, status, description, created_at, updated_at, campaign_id, post_id`
+	row := s.db.QueryRow(query, externalId, payerID, payeeID, amount, types.StatusPending, description, campaignID, postID)
 
 	return ScanRowIntoTransaction(row)
 }
 
 func (s *Store) UpdateTransactionStatusAndAmount(externalId string, status types.TransactionStatus, amount float64) (*types.Transaction, error) {
-	query := `UPDATE transactions SET status = $1 WHERE external_id = $2 RETURNING id, external_id, payer_id, payee_id, amount, status, description, created_at, updated_at`
+	query := `UPDATE transactions SET status = $1 WHERE external_id = $2 RETURNING id, external_id, payer_id, payee_id, amount, status, description, created_at, updated_at, campaign_id, post_id`
 	row := s.db.QueryRow(query, status, externalId)
 
 	return ScanRowIntoTransaction(row)
 }
 
+// CompleteTransaction marks a pending transaction as done with the amount
+// actually paid. It returns nil when the transaction was already done, so
+// only the first of concurrent or repeated confirmations goes through.
+func (s *Store) CompleteTransaction(externalID string, amount float64) (*types.Transaction, error) {
+	query := `UPDATE transactions SET status = $1, amount = $2, updated_at = NOW() WHERE external_id = $3 AND status <> $1 RETURNING id, external_id, payer_id, payee_id, amount, status, description, created_at, updated_at, campaign_id, post_id`
+	row := s.db.QueryRow(query, types.StatusDone, amount, externalID)
+
+	transaction, err := ScanRowIntoTransaction(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return transaction, err
+}
+
 func (s *Store) GetTransactionByExternalID(externalID string) (*types.Transaction, error) {
-	query := `SELECT id, external_id, payer_id, payee_id, amount, status, description, created_at, updated_at FROM transactions WHERE external_id = $1`
+	query := `SELECT id, external_id, payer_id, payee_id, amount, status, description, created_at, updated_at, campaign_id, post_id FROM transactions WHERE external_id = $1`
 	row := s.db.QueryRow(query, externalID)
 
 	return ScanRowIntoTransaction(row)
 }
+
+// GetReceivedAmountSince sums the confirmed donations the payee received
+// since the given time, plus the pending ones created after pendingSince,
+// which can still be paid.
+func (s *Store) GetReceivedAmountSince(payeeID int, since time.Time, pendingSince time.Time) (float64, error) {
+	query := `
+	SELECT COALESCE(SUM(amount), 0)::float8
+	FROM transactions
+	WHERE payee_id = $1
+	  AND ((status = $2 AND updated_at >= $3) OR (status = $4 AND created_at >= $5))
+	`
+
+	var amount float64
+	if err := s.db.QueryRow(query, payeeID, types.StatusDone, since, types.StatusPending, pendingSince).Scan(&amount); err != nil {
+		return 0, fmt.Errorf("error getting received amount: %w", err)
+	}
+
+	return amount, nil
+}
@@ -0,0 +1,184 @@
+package user
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alissoncorsair/appsolidario-backend/config"
+	"github.com/alissoncorsair/appsolidario-backend/service/auth"
+	"github.com/alissoncorsair/appsolidario-backend/service/login_attempt"
+	"github.com/alissoncorsair/appsolidario-backend/service/token"
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+	"github.com/go-playground/validator/v10"
+)
+
+func lockoutDuration() time.Duration {
+	return time.Duration(config.Envs.LoginLockoutDurationInSeconds) * time.Second
+}
+
+// backoffDelay doubles the wait for every failure past the free attempts,
+// capped at the lockout duration.
+func backoffDelay(failures int64) time.Duration {
+	extra := failures - config.Envs.LoginBackoffAfterAttempts
+	if extra <= 0 {
+		return 0
+	}
+
+	seconds := float64(config.Envs.LoginBackoffBaseSeconds) * math.Pow(2, float64(extra-1))
+	delay := time.Duration(math.Min(seconds, float64(config.Envs.LoginLockoutDurationInSeconds))) * time.Second
+
+	return delay
+}
+
+// checkLoginBlocked writes a 429 and returns true when the CPF or the IP
+// still has to wait before trying again.
+func (h *Handler) checkLoginBlocked(w http.ResponseWriter, keys ...string) bool {
+	for _, key := range keys {
+		attempt, err := h.loginAttemptStore.GetLoginAttempt(key)
+
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return true
+		}
+
+		if attempt == nil || attempt.BlockedUntil == nil || !time.Now().Before(*attempt.BlockedUntil) {
+			continue
+		}
+
+		retryAfter := int(math.Ceil(time.Until(*attempt.BlockedUntil).Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("muitas tentativas de login, tente novamente em %d segundos", retryAfter))
+		return true
+	}
+
+	return false
+}
+
+// registerLoginFailure records a failed attempt for the CPF and the IP. When
+// the CPF reaches the limit the account is locked and its owner, if any, gets
+// an email to unlock it.
+func (h *Handler) registerLoginFailure(cpf string, ip string, user *types.User) {
+	window := lockoutDuration()
+
+	ipAttempt, err := h.loginAttemptStore.RegisterFailure(login_attempt.IPKey(ip), window)
+	if err != nil {
+		log.Printf("failed to register login failure: %v", err)
+	} else if ipAttempt.Failures >= config.Envs.LoginMaxAttemptsPerIP {
+		if err := h.loginAttemptStore.BlockUntil(ipAttempt.Key, time.Now().Add(window)); err != nil {
+			log.Printf("failed to block ip: %v", err)
+		}
+	}
+
+	cpfAttempt, err := h.loginAttemptStore.RegisterFailure(login_attempt.CPFKey(cpf), window)
+	if err != nil {
+		log.Printf("failed to register login failure: %v", err)
+		return
+	}
+
+	if cpfAttempt.Failures < config.Envs.LoginMaxAttemptsPerCPF {
+		if delay := backoffDelay(cpfAttempt.Failures); delay > 0 {
+			if err := h.loginAttemptStore.BlockUntil(cpfAttempt.Key, time.Now().Add(delay)); err != nil {
+				log.Printf("failed to block cpf: %v", err)
+			}
+		}
+		return
+	}
+
+	lockedUntil := time.Now().Add(window)
+	if err := h.loginAttemptStore.BlockUntil(cpfAttempt.Key, lockedUntil); err != nil {
+		log.Printf("failed to lock account: %v", err)
+		return
+	}
+
+	// only warn the owner once per lockout
+	if user == nil || cpfAttempt.Failures != config.Envs.LoginMaxAttemptsPerCPF {
+		return
+	}
+
+	unlockToken, err := token.Generate()
+	if err != nil {
+		log.Printf("failed to generate unlock token: %v", err)
+		return
+	}
+
+	err = h.tokenStore.DeleteUserTokens(user.ID, types.TokenTypeUnlock)
+	if err != nil {
+		log.Printf("failed to delete unlock tokens: %v", err)
+		return
+	}
+
+	err = auth.StoreToken(h.tokenStore, &types.Token{
+		UserID:    user.ID,
+		Token:     token.Hash(unlockToken),
+		Type:      types.TokenTypeUnlock,
+		ExpiresAt: lockedUntil,
+	})
+	if err != nil {
+		log.Printf("failed to store unlock token: %v", err)
+		return
+	}
+
+	if err := h.mailer.SendAccountLockedEmail(user, unlockToken, lockedUntil); err != nil {
+		log.Printf("failed to send account locked email: %v", err)
+	}
+}
+
+func (h *Handler) HandleUnlockAccount(w http.ResponseWriter, r *http.Request) {
+	var payload types.UnlockAccountRequest
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	unlockToken, err := h.tokenStore.GetToken(token.Hash(payload.Token))
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if unlockToken == nil || unlockToken.Type != types.TokenTypeUnlock || time.Now().After(unlockToken.ExpiresAt) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("link de desbloqueio inválido ou expirado"))
+		return
+	}
+
+	user, err := h.userStore.GetUserByID(unlockToken.UserID)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if user == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("user not found"))
+		return
+	}
+
+	err = h.loginAttemptStore.ResetLoginAttempts(login_attempt.CPFKey(user.CPF))
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = h.tokenStore.DeleteUserTokens(user.ID, types.TokenTypeUnlock)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Conta desbloqueada com sucesso"})
+}
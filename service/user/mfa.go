@@ -0,0 +1,289 @@
+package user
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alissoncorsair/appsolidario-backend/service/auth"
+	"github.com/alissoncorsair/appsolidario-backend/service/login_attempt"
+	"github.com/alissoncorsair/appsolidario-backend/service/mailer"
+	"github.com/alissoncorsair/appsolidario-backend/service/token"
+	"github.com/alissoncorsair/appsolidario-backend/totp"
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const recoveryCodeCount = 10
+
+// normalizeRecoveryCode lets users type the code with or without the dash
+// and in any case.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		random, err := token.Generate()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		code := random[:5] + "-" + random[5:10]
+		codes = append(codes, code)
+		hashes = append(hashes, token.Hash(normalizeRecoveryCode(code)))
+	}
+
+	return codes, hashes, nil
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code.
+func (h *Handler) verifySecondFactor(userMFA *types.UserMFA, code string, recoveryCode string) (bool, error) {
+	if recoveryCode != "" {
+		return h.mfaStore.UseRecoveryCode(userMFA.UserID, token.Hash(normalizeRecoveryCode(recoveryCode)))
+	}
+
+	step, ok := totp.Validate(userMFA.Secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	return h.mfaStore.UseStep(userMFA.UserID, step)
+}
+
+func (h *Handler) HandleLoginMFA(w http.ResponseWriter, r *http.Request) {
+	var payload types.MFALoginRequest
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	validatedToken, err := auth.ValidateToken(payload.MFAToken, types.TokenTypeMFAPending)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid mfa token"))
+		return
+	}
+
+	claims, ok := validatedToken.Claims.(jwt.MapClaims)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid token claims"))
+		return
+	}
+
+	userIDStr, _ := claims["userID"].(string)
+	userID, err := strconv.Atoi(userIDStr)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid user ID"))
+		return
+	}
+
+	user, err := h.userStore.GetUserByID(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if user == nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid mfa token"))
+		return
+	}
+
+	// wrong codes count towards the same lockout as wrong passwords
+	ip := utils.GetClientIP(r)
+	if h.checkLoginBlocked(w, login_attempt.CPFKey(user.CPF), login_attempt.IPKey(ip)) {
+		return
+	}
+
+	userMFA, err := h.mfaStore.GetUserMFA(user.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if userMFA == nil || !userMFA.Enabled {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("2FA is not enabled"))
+		return
+	}
+
+	valid, err := h.verifySecondFactor(userMFA, payload.Code, payload.RecoveryCode)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !valid {
+		h.registerLoginFailure(user.CPF, ip, user)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("código inválido"))
+		return
+	}
+
+	if err := h.loginAttemptStore.ResetLoginAttempts(login_attempt.CPFKey(user.CPF)); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.startSession(w, r, user, payload.DeviceName)
+}
+
+func (h *Handler) HandleEnrollMFA(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	userMFA, err := h.mfaStore.GetUserMFA(user.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if userMFA != nil && userMFA.Enabled {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("2FA is already enabled"))
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = h.mfaStore.CreateUserMFA(user.ID, secret, hashes)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to enroll 2FA: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"secret":         secret,
+		"otpauth_uri":    totp.URI(mailer.FromName, user.Email, secret),
+		"recovery_codes": codes,
+	})
+}
+
+func (h *Handler) HandleConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	var payload types.MFACodeRequest
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	userID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	userMFA, err := h.mfaStore.GetUserMFA(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if userMFA == nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("2FA enrollment not started"))
+		return
+	}
+
+	if userMFA.Enabled {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("2FA is already enabled"))
+		return
+	}
+
+	valid, err := h.verifySecondFactor(userMFA, payload.Code, "")
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !valid {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("código inválido"))
+		return
+	}
+
+	if err := h.mfaStore.EnableUserMFA(userID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Autenticação em dois fatores ativada"})
+}
+
+func (h *Handler) HandleDisableMFA(w http.ResponseWriter, r *http.Request) {
+	var payload types.DisableMFARequest
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	userID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	userMFA, err := h.mfaStore.GetUserMFA(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if userMFA == nil || !userMFA.Enabled {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("2FA is not enabled"))
+		return
+	}
+
+	valid, err := h.verifySecondFactor(userMFA, payload.Code, payload.RecoveryCode)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !valid {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("código inválido"))
+		return
+	}
+
+	if err := h.mfaStore.DeleteUserMFA(userID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Autenticação em dois fatores desativada"})
+}
@@ -0,0 +1,109 @@
+package user
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/alissoncorsair/appsolidario-backend/service/auth"
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+)
+
+// DefaultPrivacySettings applies to users that never changed their settings.
+func DefaultPrivacySettings() *types.PrivacySettings {
+	return &types.PrivacySettings{
+		ShowCity:          true,
+		ShowState:         true,
+		ShowDescription:   true,
+		ShowPoints:        true,
+		ShowBirthDate:     false,
+		ShowOnLeaderboard: true,
+	}
+}
+
+// PublicProfile is the only way a user should be serialized to someone other
+// than its owner: CPF, email, postal code and status are never included and
+// the rest follows the owner's privacy settings.
+func PublicProfile(u *types.UserWithoutPassword, settings *types.PrivacySettings) *types.PublicUser {
+	if settings == nil {
+		settings = DefaultPrivacySettings()
+	}
+
+	public := &types.PublicUser{
+		ID:          u.ID,
+		Name:        u.Name,
+		Surname:     u.Surname,
+		UserPicture: u.UserPicture,
+		RoleID:      u.RoleID,
+		IsVerified:  u.IsVerified,
+		CreatedAt:   u.CreatedAt,
+	}
+
+	if settings.ShowCity {
+		public.City = u.City
+	}
+
+	if settings.ShowState {
+		public.State = u.State
+	}
+
+	if settings.ShowDescription {
+		public.Description = u.Description
+	}
+
+	if settings.ShowPoints {
+		points := u.Points
+		public.Points = &points
+	}
+
+	if settings.ShowBirthDate {
+		birthDate := u.BirthDate
+		public.BirthDate = &birthDate
+	}
+
+	return public
+}
+
+func (h *Handler) HandleGetPrivacySettings(w http.ResponseWriter, r *http.Request) {
+	userID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	settings, err := h.userStore.GetPrivacySettings(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, settings)
+}
+
+func (h *Handler) HandleUpdatePrivacySettings(w http.ResponseWriter, r *http.Request) {
+	userID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	settings, err := h.userStore.GetPrivacySettings(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// fields missing from the body keep their current value
+	if err := utils.ParseJSON(r, settings); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	settings, err = h.userStore.UpdatePrivacySettings(userID, settings)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to update privacy settings: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, settings)
+}
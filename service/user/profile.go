@@ -0,0 +1,150 @@
+package user
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/alissoncorsair/appsolidario-backend/geo"
+	"github.com/alissoncorsair/appsolidario-backend/service/auth"
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+	"github.com/go-playground/validator/v10"
+)
+
+// auditedFields are the profile fields whose changes go to the audit log.
+var auditedFields = map[string]bool{
+	"name":        true,
+	"surname":     true,
+	"postal_code": true,
+	"city":        true,
+	"state":       true,
+}
+
+var nonDigits = regexp.MustCompile("[^0-9]")
+
+func (h *Handler) HandleUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	var payload types.UpdateProfileRequest
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// the limits apply to what is saved, so trim before validating
+	for _, field := range []*string{payload.Name, payload.Surname, payload.PostalCode, payload.City, payload.State, payload.Description} {
+		if field != nil {
+			*field = strings.TrimSpace(*field)
+		}
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	current, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	updated := *current
+	var changes []types.AuditLogEntry
+
+	setField := func(field string, target *string, value *string) {
+		if value == nil {
+			return
+		}
+
+		newValue := *value
+		if newValue == *target {
+			return
+		}
+
+		if auditedFields[field] {
+			changes = append(changes, types.AuditLogEntry{Field: field, OldValue: *target, NewValue: newValue})
+		}
+
+		*target = newValue
+	}
+
+	if payload.PostalCode != nil {
+		postalCode := nonDigits.ReplaceAllString(*payload.PostalCode, "")
+
+		if len(postalCode) != 8 {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("cep inválido"))
+			return
+		}
+
+		payload.PostalCode = &postalCode
+	}
+
+	if payload.City != nil || payload.State != nil || payload.PostalCode != nil {
+		city, state, postalCode := updated.City, updated.State, updated.PostalCode
+
+		if payload.City != nil {
+			city = *payload.City
+		}
+
+		if payload.State != nil {
+			state = *payload.State
+		}
+
+		if payload.PostalCode != nil {
+			postalCode = *payload.PostalCode
+		}
+
+		if strings.TrimSpace(city) == "" {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("cidade não pode ficar vazia"))
+			return
+		}
+
+		address, err := geo.Normalize(city, state, postalCode)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		payload.City = &address.City
+		payload.State = &address.State
+		updated.CityIBGECode = address.CityIBGECode
+		updated.Latitude = address.Latitude
+		updated.Longitude = address.Longitude
+	}
+
+	setField("name", &updated.Name, payload.Name)
+	setField("surname", &updated.Surname, payload.Surname)
+	setField("postal_code", &updated.PostalCode, payload.PostalCode)
+	setField("city", &updated.City, payload.City)
+	setField("state", &updated.State, payload.State)
+
+	if payload.Description != nil {
+		updated.Description = payload.Description
+	}
+
+	if updated.Name == "" || updated.Surname == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("nome e sobrenome não podem ficar vazios"))
+		return
+	}
+
+	user, err := h.userStore.UpdateUserProfile(&updated, changes, utils.GetClientIP(r))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to update profile: %w", err))
+		return
+	}
+
+	profilePicture, err := h.userStore.GetUserProfilePicture(user.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get profile picture: %w", err))
+		return
+	}
+
+	if profilePicture != nil {
+		user.UserPicture = profilePicture.Path
+	}
+
+	utils.WriteJSON(w, http.StatusOK, user.UserWithoutPassword)
+}
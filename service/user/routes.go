@@ -3,16 +3,23 @@ package user
 import (
 	"database/sql"
 	"fmt"
+	"log"
 	"net/http"
 	"regexp"
 	"strconv"
 	"time"
 
 	"github.com/alissoncorsair/appsolidario-backend/config"
+	"github.com/alissoncorsair/appsolidario-backend/geo"
 	"github.com/alissoncorsair/appsolidario-backend/service/auth"
+	"github.com/alissoncorsair/appsolidario-backend/service/gamification"
+	"github.com/alissoncorsair/appsolidario-backend/service/login_attempt"
 	"github.com/alissoncorsair/appsolidario-backend/service/mailer"
+	"github.com/alissoncorsair/appsolidario-backend/service/mfa"
 	"github.com/alissoncorsair/appsolidario-backend/service/notification"
 	"github.com/alissoncorsair/appsolidario-backend/service/profile_picture"
+	"github.com/alissoncorsair/appsolidario-backend/service/session"
+	"github.com/alissoncorsair/appsolidario-backend/service/token"
 	"github.com/alissoncorsair/appsolidario-backend/storage"
 	"github.com/alissoncorsair/appsolidario-backend/types"
 	"github.com/alissoncorsair/appsolidario-backend/utils"
@@ -25,17 +32,27 @@ type Handler struct {
 	userStore         *Store
 	pictureStore      *profile_picture.Store
 	notificationStore *notification.Store
+	tokenStore        *token.Store
+	sessionStore      *session.Store
+	loginAttemptStore *login_attempt.Store
+	mfaStore          *mfa.Store
 	storage           *storage.R2Storage
 	mailer            mailer.Mailer
+	gamification      *gamification.Engine
 }
 
-func NewHandler(userStore *Store, pictureStore *profile_picture.Store, notificationStore *notification.Store, storage *storage.R2Storage, mailer mailer.Mailer) *Handler {
+func NewHandler(userStore *Store, pictureStore *profile_picture.Store, notificationStore *notification.Store, tokenStore *token.Store, sessionStore *session.Store, loginAttemptStore *login_attempt.Store, mfaStore *mfa.Store, storage *storage.R2Storage, mailer mailer.Mailer, gamification *gamification.Engine) *Handler {
 	return &Handler{
 		userStore:         userStore,
 		pictureStore:      pictureStore,
 		notificationStore: notificationStore,
+		tokenStore:        tokenStore,
+		sessionStore:      sessionStore,
+		loginAttemptStore: loginAttemptStore,
+		mfaStore:          mfaStore,
 		storage:           storage,
 		mailer:            mailer,
+		gamification:      gamification,
 	}
 }
 
@@ -119,18 +136,43 @@ func (h *Handler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 
 	postalCode := re.ReplaceAllString(payload.PostalCode, "")
 
+	address, err := geo.Normalize(payload.City, payload.State, postalCode)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if payload.ReferrerID != nil {
+		referrer, err := h.userStore.GetUserByID(*payload.ReferrerID)
+
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if referrer == nil || referrer.Status != types.StatusActive {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("usuário que indicou não encontrado"))
+			return
+		}
+	}
+
 	user, err := h.userStore.CreateUser(&types.User{
 		UserWithoutPassword: types.UserWithoutPassword{
-			Name:       payload.Name,
-			Surname:    payload.Surname,
-			Email:      payload.Email,
-			PostalCode: postalCode,
-			State:      payload.State,
-			City:       payload.City,
-			Status:     types.StatusInactive,
-			RoleID:     types.UserRole(roleID),
-			CPF:        cpf,
-			BirthDate:  birthDate,
+			Name:         payload.Name,
+			Surname:      payload.Surname,
+			Email:        payload.Email,
+			PostalCode:   postalCode,
+			State:        address.State,
+			City:         address.City,
+			CityIBGECode: address.CityIBGECode,
+			Latitude:     address.Latitude,
+			Longitude:    address.Longitude,
+			Status:       types.StatusInactive,
+			RoleID:       types.UserRole(roleID),
+			CPF:          cpf,
+			BirthDate:    birthDate,
+			ReferredBy:   payload.ReferrerID,
 		},
 		Password: hashedPass,
 	})
@@ -140,7 +182,7 @@ func (h *Handler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	activationToken, err := auth.CreateJWT([]byte(config.Envs.JWTSecret), user.ID, types.TokenTypeVerify, time.Hour*24)
+	activationToken, err := auth.CreateJWT(user.ID, types.TokenTypeVerify, time.Hour*24)
 
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
@@ -173,6 +215,11 @@ func (h *Handler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	var cpf string
 	re := regexp.MustCompile("[^0-9]")
 	cpf = re.ReplaceAllString(payload.CPF, "")
+	ip := utils.GetClientIP(r)
+
+	if h.checkLoginBlocked(w, login_attempt.CPFKey(cpf), login_attempt.IPKey(ip)) {
+		return
+	}
 
 	user, err := h.userStore.GetUserByCPF(cpf)
 
@@ -183,54 +230,98 @@ func (h *Handler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if user == nil {
+		h.registerLoginFailure(cpf, ip, nil)
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("CPF/Senha inválidos"))
 		return
 	}
 
 	if !auth.ComparePassword(user.Password, []byte(payload.Password)) {
+		h.registerLoginFailure(cpf, ip, user)
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("CPF/Senha inválidos"))
 		return
 	}
 
+	if err := h.loginAttemptStore.ResetLoginAttempts(login_attempt.CPFKey(cpf)); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
 	if user.Status == types.StatusInactive {
 		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("email not verified"))
 		return
 	}
 
-	accessToken, err := auth.CreateJWT([]byte(config.Envs.JWTSecret), user.ID, types.TokenTypeAccess, time.Duration(config.Envs.JWTExpirationInSeconds)*time.Second)
+	mfa, err := h.mfaStore.GetUserMFA(user.ID)
 
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	refreshToken, err := auth.CreateJWT([]byte(config.Envs.JWTSecret), user.ID, types.TokenTypeRefresh, time.Duration(config.Envs.JWTRefreshExpirationInSeconds)*time.Second)
+	if mfa != nil && mfa.Enabled {
+		mfaToken, err := auth.CreateJWT(user.ID, types.TokenTypeMFAPending, 5*time.Minute)
+
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+		})
+		return
+	}
+
+	h.startSession(w, r, user, payload.DeviceName)
+}
+
+// startSession records a new session for the user and responds with its
+// access and refresh tokens.
+func (h *Handler) startSession(w http.ResponseWriter, r *http.Request, user *types.User, deviceName string) {
+	userSession, err := h.sessionStore.CreateSession(&types.Session{
+		UserID:     user.ID,
+		DeviceName: deviceName,
+		UserAgent:  r.UserAgent(),
+		IPAddress:  utils.GetClientIP(r),
+	})
 
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to create session: %w", err))
 		return
 	}
 
-	response := struct {
-		AccessToken  string `json:"access_token"`
-		RefreshToken string `json:"refresh_token"`
-	}{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+	pair, err := auth.IssueTokenPair(h.tokenStore, user.ID, userSession.ID)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, response)
+	utils.WriteJSON(w, http.StatusOK, pair)
 }
 
 func (h *Handler) HandleVerify(w http.ResponseWriter, r *http.Request) {
-	token := r.URL.Query().Get("token")
+	rawToken := r.URL.Query().Get("token")
 
-	if token == "" {
+	if rawToken == "" {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("token is required"))
 		return
 	}
 
-	validatedToken, err := auth.ValidateToken(token, types.TokenTypeVerify)
+	// links sent by POST /me/email carry an opaque token instead of a JWT
+	changeToken, err := h.tokenStore.GetToken(token.Hash(rawToken))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if changeToken != nil && changeToken.Type == types.TokenTypeEmailChange {
+		h.confirmEmailChange(w, r, changeToken)
+		return
+	}
+
+	validatedToken, err := auth.ValidateToken(rawToken, types.TokenTypeVerify)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid token"))
 		return
@@ -260,6 +351,11 @@ func (h *Handler) HandleVerify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if user == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("user not found"))
+		return
+	}
+
 	if user.Status == types.StatusActive {
 		utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Email already verified"})
 		return
@@ -271,6 +367,8 @@ func (h *Handler) HandleVerify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.gamification.EmailVerified(user)
+
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Email verified successfully"})
 }
 
@@ -382,6 +480,7 @@ func (h *Handler) HandleGetProfile(id int, w http.ResponseWriter, r *http.Reques
 		City:        user.City,
 		Status:      user.Status,
 		RoleID:      user.RoleID,
+		IsVerified:  user.IsVerified,
 		CPF:         user.CPF,
 		BirthDate:   user.BirthDate,
 		Description: user.Description,
@@ -390,7 +489,58 @@ func (h *Handler) HandleGetProfile(id int, w http.ResponseWriter, r *http.Reques
 		UpdatedAt:   user.UpdatedAt,
 	}
 
-	utils.WriteJSON(w, http.StatusOK, userWithoutPassword)
+	counts, err := h.userStore.GetFollowCounts(user.ID)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	viewerID, _ := auth.GetUserIDFromContext(r.Context())
+
+	if viewerID == user.ID {
+		utils.WriteJSON(w, http.StatusOK, struct {
+			types.UserWithoutPassword
+			types.FollowCounts
+		}{userWithoutPassword, *counts})
+		return
+	}
+
+	settings, err := h.userStore.GetPrivacySettings(user.ID)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	following, err := h.userStore.IsFollowing(viewerID, user.ID)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	blocked, err := h.userStore.IsBlocked(viewerID, user.ID)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	muted, err := h.userStore.IsMuted(viewerID, user.ID)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, struct {
+		*types.PublicUser
+		types.FollowCounts
+		IsFollowing bool `json:"is_following"`
+		IsBlocked   bool `json:"is_blocked"`
+		IsMuted     bool `json:"is_muted"`
+	}{PublicProfile(&userWithoutPassword, settings), *counts, following, blocked, muted})
 }
 
 func (h *Handler) HandleGetOwnProfile(w http.ResponseWriter, r *http.Request) {
@@ -425,6 +575,7 @@ func (h *Handler) HandleGetGivenProfile(w http.ResponseWriter, r *http.Request)
 
 type NotificationResponse struct {
 	ID          int                      `json:"id"`
+	Type        types.Type               `json:"type"`
 	IsRead      bool                     `json:"isRead"`
 	CreatedAt   time.Time                `json:"createdAt"`
 	FromUser    notification.MinimalUser `json:"fromUser"`
@@ -440,16 +591,23 @@ func (h *Handler) HandleGetNotifications(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	notifications, err := h.notificationStore.GetNotificationsByUserID(userID)
+	page, err := utils.ParsePageRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	notifications, err := h.notificationStore.GetNotificationsByUserID(userID, page)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get notifications: %w", err))
 		return
 	}
 
 	response := make([]NotificationResponse, 0)
-	for _, n := range notifications {
+	for _, n := range notifications.Items {
 		resp := NotificationResponse{
 			ID:        n.ID,
+			Type:      n.Type,
 			IsRead:    n.IsRead,
 			CreatedAt: n.CreatedAt,
 			FromUser:  n.FromUser,
@@ -458,7 +616,11 @@ func (h *Handler) HandleGetNotifications(w http.ResponseWriter, r *http.Request)
 		response = append(response, resp)
 	}
 
-	utils.WriteJSON(w, http.StatusOK, response)
+	utils.WriteJSON(w, http.StatusOK, utils.Page[NotificationResponse]{
+		Items:      response,
+		Limit:      notifications.Limit,
+		NextCursor: notifications.NextCursor,
+	})
 }
 
 func (h *Handler) HandleReadNotification(w http.ResponseWriter, r *http.Request) {
@@ -533,15 +695,39 @@ func (h *Handler) HandleGetUsersByCity(w http.ResponseWriter, r *http.Request) {
 		city = user.City
 	}
 
-	users, err := h.userStore.GetUsersByCity(city)
+	page, err := utils.ParsePageRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	users, err := h.userStore.GetUsersByCity(userID, geo.CanonicalCityName(city), page)
 
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get users: %w", err))
 		return
 	}
 
-	if users == nil {
-		users = []*types.User{}
+	utils.WriteJSON(w, http.StatusOK, users)
+}
+
+func (h *Handler) HandleGetUsersNearby(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	query, err := utils.ParseNearbyQuery(r, user.Latitude, user.Longitude)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	users, err := h.userStore.GetUsersNearby(user.ID, query.Latitude, query.Longitude, query.RadiusKm, query.Page)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get users: %w", err))
+		return
 	}
 
 	utils.WriteJSON(w, http.StatusOK, users)
@@ -601,7 +787,7 @@ func (h *Handler) HandleUpdateDescription(w http.ResponseWriter, r *http.Request
 		user.UserPicture = profilePicture.Path
 	}
 
-	utils.WriteJSON(w, http.StatusOK, user)
+	utils.WriteJSON(w, http.StatusOK, user.UserWithoutPassword)
 }
 
 func (h *Handler) HandleResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
@@ -630,7 +816,7 @@ func (h *Handler) HandleResendVerificationEmail(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	activationToken, err := auth.CreateJWT([]byte(config.Envs.JWTSecret), user.ID, types.TokenTypeVerify, time.Hour*24)
+	activationToken, err := auth.CreateJWT(user.ID, types.TokenTypeVerify, time.Hour*24)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
@@ -645,19 +831,491 @@ func (h *Handler) HandleResendVerificationEmail(w http.ResponseWriter, r *http.R
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Email de verificação reenviado com sucesso"})
 }
 
+func (h *Handler) HandleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var payload types.ForgotPasswordRequest
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	// the response is the same whether the email exists or not, so this
+	// endpoint can't be used to find out who is registered
+	response := map[string]string{"message": "Se o email estiver cadastrado, você receberá um link para redefinir sua senha"}
+
+	user, err := h.userStore.GetUserByEmail(payload.Email)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if user == nil {
+		utils.WriteJSON(w, http.StatusOK, response)
+		return
+	}
+
+	// only the latest link is valid
+	err = h.tokenStore.DeleteUserTokens(user.ID, types.TokenTypeReset)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resetToken, err := token.Generate()
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = auth.StoreToken(h.tokenStore, &types.Token{
+		UserID:    user.ID,
+		Token:     token.Hash(resetToken),
+		Type:      types.TokenTypeReset,
+		ExpiresAt: time.Now().Add(time.Duration(config.Envs.PasswordResetExpirationInSeconds) * time.Second),
+	})
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to store reset token: %w", err))
+		return
+	}
+
+	err = h.mailer.SendPasswordResetEmail(user, resetToken)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to send password reset email: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) HandleResetPassword(w http.ResponseWriter, r *http.Request) {
+	var payload types.ResetPasswordRequest
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	resetToken, err := h.tokenStore.GetToken(token.Hash(payload.Token))
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if resetToken == nil || resetToken.Type != types.TokenTypeReset || time.Now().After(resetToken.ExpiresAt) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("link de redefinição inválido ou expirado"))
+		return
+	}
+
+	hashedPass, err := auth.HashPassword(payload.Password)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// the token goes before the password changes, so two concurrent requests
+	// can't both use it
+	consumed, err := h.tokenStore.ConsumeToken(resetToken.ID)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !consumed {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("link de redefinição inválido ou expirado"))
+		return
+	}
+
+	err = h.userStore.UpdateUserPassword(resetToken.UserID, hashedPass)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// whoever took over the account must not stay logged in, and their
+	// refresh tokens die with the sessions
+	err = h.sessionStore.RevokeUserSessions(resetToken.UserID)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = h.tokenStore.DeleteUserTokens(resetToken.UserID, types.TokenTypeReset)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = h.tokenStore.DeleteUserTokens(resetToken.UserID, types.TokenTypeEmailChange)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Senha redefinida com sucesso"})
+}
+
+func (h *Handler) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	var payload types.RefreshTokenRequest
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	stored, err := auth.GetRefreshToken(h.tokenStore, payload.RefreshToken)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if stored != nil {
+		if _, err := h.sessionStore.RevokeSession(*stored.SessionID, stored.UserID); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Logout realizado com sucesso"})
+}
+
+func (h *Handler) HandleLogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, found := auth.GetUserIDFromContext(r.Context())
+
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	err := h.sessionStore.RevokeUserSessions(userID)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Todas as sessões foram encerradas"})
+}
+
+func (h *Handler) HandleGetSessions(w http.ResponseWriter, r *http.Request) {
+	userID, found := auth.GetUserIDFromContext(r.Context())
+
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	currentSessionID, _ := auth.GetSessionIDFromContext(r.Context())
+
+	sessions, err := h.sessionStore.GetSessionsByUserID(userID)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get sessions: %w", err))
+		return
+	}
+
+	for _, s := range sessions {
+		s.Current = s.ID == currentSessionID
+	}
+
+	utils.WriteJSON(w, http.StatusOK, sessions)
+}
+
+func (h *Handler) HandleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, found := auth.GetUserIDFromContext(r.Context())
+
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	sessionID, err := strconv.Atoi(r.PathValue("id"))
+
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid session ID"))
+		return
+	}
+
+	revoked, err := h.sessionStore.RevokeSession(sessionID, userID)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to revoke session: %w", err))
+		return
+	}
+
+	if !revoked {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("session not found"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Sessão encerrada com sucesso"})
+}
+
+func (h *Handler) HandleChangePassword(w http.ResponseWriter, r *http.Request) {
+	var payload types.ChangePasswordRequest
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	sessionID, _ := auth.GetSessionIDFromContext(r.Context())
+
+	if !auth.ComparePassword(user.Password, []byte(payload.CurrentPassword)) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("senha atual incorreta"))
+		return
+	}
+
+	hashedPass, err := auth.HashPassword(payload.NewPassword)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.userStore.UpdateUserPassword(user.ID, hashedPass); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = h.userStore.AddAuditLog(user.ID, []types.AuditLogEntry{{Field: "password"}}, utils.GetClientIP(r))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// whoever knew the old password may still be logged in elsewhere
+	if err := h.sessionStore.RevokeOtherUserSessions(user.ID, sessionID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.tokenStore.DeleteUserTokens(user.ID, types.TokenTypeReset); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// a pending email change may have been asked for by whoever knew the old
+	// password
+	if err := h.tokenStore.DeleteUserTokens(user.ID, types.TokenTypeEmailChange); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Senha alterada com sucesso"})
+}
+
+func (h *Handler) HandleChangeEmail(w http.ResponseWriter, r *http.Request) {
+	var payload types.ChangeEmailRequest
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	if !auth.ComparePassword(user.Password, []byte(payload.Password)) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("senha incorreta"))
+		return
+	}
+
+	if payload.Email == user.Email {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("o novo email é igual ao atual"))
+		return
+	}
+
+	existingUser, err := h.userStore.GetUserByEmail(payload.Email)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if existingUser != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("o email %s já foi cadastrado", payload.Email))
+		return
+	}
+
+	// only the latest link works
+	if err := h.tokenStore.DeleteUserTokens(user.ID, types.TokenTypeEmailChange); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	verifyToken, err := token.Generate()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	_, err = h.tokenStore.CreateToken(&types.Token{
+		UserID:    user.ID,
+		Token:     token.Hash(verifyToken),
+		Type:      types.TokenTypeEmailChange,
+		ExpiresAt: time.Now().Add(time.Hour * 24),
+		Email:     &payload.Email,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = h.mailer.SendEmailChangeEmail(user, payload.Email, verifyToken)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to send confirmation email: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Enviamos um link de confirmação para o novo email"})
+}
+
+// confirmEmailChange switches the user's email once the link sent to the new
+// address has been opened, and lets the old address know. The link can only
+// be used once.
+func (h *Handler) confirmEmailChange(w http.ResponseWriter, r *http.Request, changeToken *types.Token) {
+	if time.Now().After(changeToken.ExpiresAt) || changeToken.Email == nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid token"))
+		return
+	}
+
+	consumed, err := h.tokenStore.ConsumeToken(changeToken.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !consumed {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid token"))
+		return
+	}
+
+	user, err := h.userStore.GetUserByID(changeToken.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if user == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("user not found"))
+		return
+	}
+
+	newEmail := *changeToken.Email
+	if user.Email == newEmail {
+		utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Email already verified"})
+		return
+	}
+
+	existingUser, err := h.userStore.GetUserByEmail(newEmail)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if existingUser != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("o email %s já foi cadastrado", newEmail))
+		return
+	}
+
+	if err := h.userStore.UpdateUserEmail(user.ID, newEmail); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = h.userStore.AddAuditLog(user.ID, []types.AuditLogEntry{{Field: "email", OldValue: user.Email, NewValue: newEmail}}, utils.GetClientIP(r))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// user still has the old address
+	if err := h.mailer.SendEmailChangedNotice(user, newEmail); err != nil {
+		log.Printf("failed to send email change notice to user %d: %v", user.ID, err)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Email updated successfully"})
+}
+
 func (h *Handler) RegisterRoutes(router *http.ServeMux) {
 	router.HandleFunc("POST /login", h.HandleLogin)
 	router.HandleFunc("POST /register", h.HandleRegister)
-	router.HandleFunc("POST /refresh-token", auth.HandleTokenRefresh)
-	router.HandleFunc("GET /users", auth.WithJWTAuth(h.HandleGetUsersByCity, h.userStore))
-	router.HandleFunc("GET /cities", auth.WithJWTAuth(h.HandleGetCities, h.userStore))
-	router.HandleFunc("POST /profile-picture", auth.WithJWTAuth(h.HandleAddProfilePicture, h.userStore))
-	router.HandleFunc("POST /description", auth.WithJWTAuth(h.HandleUpdateDescription, h.userStore))
-	router.HandleFunc("GET /profile/{id}", auth.WithJWTAuth(h.HandleGetGivenProfile, h.userStore))
-	router.HandleFunc("GET /profile", auth.WithJWTAuth(h.HandleGetOwnProfile, h.userStore))
-	router.HandleFunc("POST /auth", auth.WithJWTAuth(h.HandleTest, h.userStore))
+	router.HandleFunc("POST /refresh-token", auth.HandleTokenRefresh(h.tokenStore, h.sessionStore))
+	router.HandleFunc("POST /logout", h.HandleLogout)
+	router.HandleFunc("POST /logout-all", auth.WithJWTAuth(h.HandleLogoutAll, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /sessions", auth.WithJWTAuth(h.HandleGetSessions, h.userStore, h.sessionStore))
+	router.HandleFunc("DELETE /sessions/{id}", auth.WithJWTAuth(h.HandleRevokeSession, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /users", auth.WithJWTAuth(h.HandleGetUsersByCity, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /users/nearby", auth.WithJWTAuth(h.HandleGetUsersNearby, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /cities", auth.WithJWTAuth(h.HandleGetCities, h.userStore, h.sessionStore))
+	router.HandleFunc("POST /profile-picture", auth.WithJWTAuth(h.HandleAddProfilePicture, h.userStore, h.sessionStore))
+	router.HandleFunc("POST /description", auth.WithJWTAuth(h.HandleUpdateDescription, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /profile/{id}", auth.WithJWTAuth(h.HandleGetGivenProfile, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /profile", auth.WithJWTAuth(h.HandleGetOwnProfile, h.userStore, h.sessionStore))
+	router.HandleFunc("POST /auth", auth.WithJWTAuth(h.HandleTest, h.userStore, h.sessionStore))
 	router.HandleFunc("GET /verify-email", h.HandleVerify)
-	router.HandleFunc("GET /notifications", auth.WithJWTAuth(h.HandleGetNotifications, h.userStore))
-	router.HandleFunc("POST /notification/{notification_id}/read", auth.WithJWTAuth(h.HandleReadNotification, h.userStore))
+	router.HandleFunc("GET /notifications", auth.WithJWTAuth(h.HandleGetNotifications, h.userStore, h.sessionStore))
+	router.HandleFunc("POST /notification/{notification_id}/read", auth.WithJWTAuth(h.HandleReadNotification, h.userStore, h.sessionStore))
 	router.HandleFunc("/resend-verification", h.HandleResendVerificationEmail)
+	router.HandleFunc("POST /forgot-password", h.HandleForgotPassword)
+	router.HandleFunc("POST /reset-password", h.HandleResetPassword)
+	router.HandleFunc("POST /unlock-account", h.HandleUnlockAccount)
+	router.HandleFunc("POST /login/mfa", h.HandleLoginMFA)
+	router.HandleFunc("POST /mfa/enroll", auth.WithJWTAuth(h.HandleEnrollMFA, h.userStore, h.sessionStore))
+	router.HandleFunc("POST /mfa/confirm", auth.WithJWTAuth(h.HandleConfirmMFA, h.userStore, h.sessionStore))
+	router.HandleFunc("POST /mfa/disable", auth.WithJWTAuth(h.HandleDisableMFA, h.userStore, h.sessionStore))
+	router.HandleFunc("POST /me/password", auth.WithJWTAuth(h.HandleChangePassword, h.userStore, h.sessionStore))
+	router.HandleFunc("POST /me/email", auth.WithJWTAuth(h.HandleChangeEmail, h.userStore, h.sessionStore))
+	router.HandleFunc("PATCH /me", auth.WithJWTAuth(h.HandleUpdateProfile, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /me/privacy", auth.WithJWTAuth(h.HandleGetPrivacySettings, h.userStore, h.sessionStore))
+	router.HandleFunc("PUT /me/privacy", auth.WithJWTAuth(h.HandleUpdatePrivacySettings, h.userStore, h.sessionStore))
 }
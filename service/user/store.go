@@ -4,8 +4,11 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/alissoncorsair/appsolidario-backend/geo"
+	"github.com/alissoncorsair/appsolidario-backend/service/moderation"
 	"github.com/alissoncorsair/appsolidario-backend/service/profile_picture"
 	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
 )
 
 type Store struct {
@@ -23,12 +26,12 @@ func (s *Store) CreateUser(user *types.User) (*types.User, error) {
 	defaultPoints := 0
 
 	query := `
-		INSERT INTO users (name, surname, email, password, status, description, postal_code, city, state, cpf, role_id, points, birth_date)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		INSERT INTO users (name, surname, email, password, status, description, postal_code, city, state, cpf, role_id, points, birth_date, city_ibge_code, latitude, longitude, referred_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 		RETURNING id
 	`
 	var id int
-	err := s.db.QueryRow(query, user.Name, user.Surname, user.Email, user.Password, defaultStatus, user.Description, user.PostalCode, user.City, user.State, user.CPF, user.RoleID, defaultPoints, user.BirthDate).Scan(&id)
+	err := s.db.QueryRow(query, user.Name, user.Surname, user.Email, user.Password, defaultStatus, user.Description, user.PostalCode, user.City, user.State, user.CPF, user.RoleID, defaultPoints, user.BirthDate, user.CityIBGECode, user.Latitude, user.Longitude, user.ReferredBy).Scan(&id)
 
 	if err != nil {
 		return nil, err
@@ -41,7 +44,7 @@ func (s *Store) CreateUser(user *types.User) (*types.User, error) {
 func (s *Store) GetUserByEmail(email string) (*types.User, error) {
 	var u *types.User
 	query := `
-	SELECT id, name, surname, email, password, status, description, postal_code, city, state, cpf, role_id, points, birth_date, created_at, updated_at
+	SELECT id, name, surname, email, password, status, description, postal_code, city, state, cpf, role_id, points, birth_date, created_at, updated_at, city_ibge_code, latitude, longitude, referred_by, is_verified
 	FROM users
 	WHERE email = $1
 	`
@@ -58,7 +61,7 @@ func (s *Store) GetUserByEmail(email string) (*types.User, error) {
 func (s *Store) GetUserByCPF(cpf string) (*types.User, error) {
 	var u *types.User
 	query := `
-	SELECT id, name, surname, email, password, status, description, postal_code, city, state, cpf, role_id, points, birth_date, created_at, updated_at
+	SELECT id, name, surname, email, password, status, description, postal_code, city, state, cpf, role_id, points, birth_date, created_at, updated_at, city_ibge_code, latitude, longitude, referred_by, is_verified
 	FROM users
 	WHERE cpf = $1
 	`
@@ -75,7 +78,7 @@ func (s *Store) GetUserByCPF(cpf string) (*types.User, error) {
 func (s *Store) GetUserByID(id int) (*types.User, error) {
 	var u *types.User
 	query := `
-	SELECT id, name, surname, email, password, status, description, postal_code, city, state, cpf, role_id, points, birth_date, created_at, updated_at
+	SELECT id, name, surname, email, password, status, description, postal_code, city, state, cpf, role_id, points, birth_date, created_at, updated_at, city_ibge_code, latitude, longitude, referred_by, is_verified
 	FROM users
 	WHERE id = $1
 	`
@@ -130,53 +133,413 @@ func (s *Store) UpdateUserStatus(userID int, status types.UserStatus) error {
 	return nil
 }
 
+func (s *Store) UpdateUserPassword(userID int, password string) error {
+	query := `
+	UPDATE users
+	SET password = $1, updated_at = NOW()
+	WHERE id = $2
+	`
+	result, err := s.db.Exec(query, password, userID)
+	if err != nil {
+		return fmt.Errorf("error updating user password: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func (s *Store) UpdateUserEmail(userID int, email string) error {
+	query := `
+	UPDATE users
+	SET email = $1, updated_at = NOW()
+	WHERE id = $2
+	`
+	result, err := s.db.Exec(query, email, userID)
+	if err != nil {
+		return fmt.Errorf("error updating user email: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
 func (s *Store) UpdateUserDescription(userID int, description string) (*types.User, error) {
 	query := `
         UPDATE users 
         SET description = $1, updated_at = NOW()
         WHERE id = $2
-        RETURNING id, name, surname, email, password, status, description, postal_code, city, state, cpf, role_id, points, birth_date, created_at, updated_at`
+        RETURNING id, name, surname, email, password, status, description, postal_code, city, state, cpf, role_id, points, birth_date, created_at, updated_at, city_ibge_code, latitude, longitude, referred_by, is_verified`
 
 	row := s.db.QueryRow(query, description, userID)
 	return ScanRowIntoUser(row)
 }
 
-func (s *Store) GetUsersByCity(city string) ([]*types.User, error) {
-	var users []*types.User
+// UpdateUserProfile saves the editable profile fields, keeps the author name
+// copied into posts and comments in sync and records the audited changes, all
+// in one transaction.
+func (s *Store) UpdateUserProfile(u *types.User, changes []types.AuditLogEntry, ipAddress string) (*types.User, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
-    SELECT u.id, u.name, u.surname, u.email, u.status, u.description, u.postal_code, u.city, u.state, u.cpf, u.role_id, u.points, u.birth_date, u.created_at, u.updated_at, pp.path
+	UPDATE users
+	SET name = $1, surname = $2, postal_code = $3, city = $4, state = $5, description = $6, city_ibge_code = $7, latitude = $8, longitude = $9, updated_at = NOW()
+	WHERE id = $10
+	RETURNING id, name, surname, email, password, status, description, postal_code, city, state, cpf, role_id, points, birth_date, created_at, updated_at, city_ibge_code, latitude, longitude, referred_by, is_verified`
+
+	updated, err := ScanRowIntoUser(tx.QueryRow(query, u.Name, u.Surname, u.PostalCode, u.City, u.State, u.Description, u.CityIBGECode, u.Latitude, u.Longitude, u.ID))
+	if err != nil {
+		return nil, fmt.Errorf("error updating user: %w", err)
+	}
+
+	if updated == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	for _, change := range changes {
+		if change.Field != "name" {
+			continue
+		}
+
+		if _, err := tx.Exec("UPDATE posts SET author_name = $1 WHERE user_id = $2", updated.Name, updated.ID); err != nil {
+			return nil, fmt.Errorf("error updating posts author: %w", err)
+		}
+
+		if _, err := tx.Exec("UPDATE comments SET author_name = $1 WHERE user_id = $2", updated.Name, updated.ID); err != nil {
+			return nil, fmt.Errorf("error updating comments author: %w", err)
+		}
+	}
+
+	if err := addAuditLog(tx, updated.ID, changes, ipAddress); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return updated, nil
+}
+
+func (s *Store) AddAuditLog(userID int, changes []types.AuditLogEntry, ipAddress string) error {
+	return addAuditLog(s.db, userID, changes, ipAddress)
+}
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func addAuditLog(db execer, userID int, changes []types.AuditLogEntry, ipAddress string) error {
+	query := `
+	INSERT INTO user_audit_log (user_id, field, old_value, new_value, ip_address)
+	VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5)`
+
+	for _, change := range changes {
+		if _, err := db.Exec(query, userID, change.Field, change.OldValue, change.NewValue, ipAddress); err != nil {
+			return fmt.Errorf("error adding audit log: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetUsersByCity lists the public profiles of the users in the city. Users
+// that hide their city, or that the viewer blocked, are left out.
+func (s *Store) GetUsersByCity(viewerID int, city string, page utils.PageRequest) (*utils.Page[*types.PublicUser], error) {
+	users := []*types.PublicUser{}
+	query := `
+    SELECT u.id, u.name, u.surname, u.email, u.status, u.description, u.postal_code, u.city, u.state, u.cpf, u.role_id, u.points, u.birth_date, u.created_at, u.updated_at, u.is_verified, pp.path,
+           ps.show_city, ps.show_state, ps.show_description, ps.show_points, ps.show_birth_date
     FROM users u
     LEFT JOIN profile_pictures pp ON u.id = pp.user_id
-    WHERE u.city = $1
+    LEFT JOIN privacy_settings ps ON u.id = ps.user_id
+    WHERE u.city = $1 AND COALESCE(ps.show_city, TRUE)
+      AND u.id > $2
+      AND ` + moderation.NotBlockedSQL("u.id", "$4") + `
+    ORDER BY u.id
+    LIMIT $3
     `
 
-	rows, err := s.db.Query(query, city)
+	rows, err := s.db.Query(query, city, page.AfterID(), page.Limit+1, viewerID)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
-		var u types.User
-		var path sql.NullString
+		u, err := scanPublicUser(rows)
+		if err != nil {
+			return nil, err
+		}
 
-		err := rows.Scan(&u.ID, &u.Name, &u.Surname, &u.Email, &u.Status, &u.Description, &u.PostalCode, &u.City, &u.State, &u.CPF, &u.RoleID, &u.Points, &u.BirthDate, &u.CreatedAt, &u.UpdatedAt, &path)
+		users = append(users, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
+	return utils.NewPage(users, page.Limit, func(u *types.PublicUser) utils.Cursor {
+		return utils.Cursor{ID: u.ID}
+	}), nil
+}
+
+// GetUsersNearby lists the public profiles of the users within radiusKm of
+// the given point, closest first. Like GetUsersByCity, users that hide their
+// city are left out, since the distance would give it away, and so are the
+// viewer and the users they blocked.
+func (s *Store) GetUsersNearby(viewerID int, latitude, longitude, radiusKm float64, page utils.PageRequest) (*utils.Page[*types.PublicUser], error) {
+	users := []*types.PublicUser{}
+	box := geo.BoundingBox(latitude, longitude, radiusKm)
+	query := `
+    SELECT * FROM (
+        SELECT u.id, u.name, u.surname, u.email, u.status, u.description, u.postal_code, u.city, u.state, u.cpf, u.role_id, u.points, u.birth_date, u.created_at, u.updated_at, u.is_verified, pp.path,
+               ps.show_city, ps.show_state, ps.show_description, ps.show_points, ps.show_birth_date,
+               ` + geo.DistanceSQL("u.latitude", "u.longitude", "$1", "$2") + ` AS distance_km
+        FROM users u
+        LEFT JOIN profile_pictures pp ON u.id = pp.user_id
+        LEFT JOIN privacy_settings ps ON u.id = ps.user_id
+        WHERE u.latitude BETWEEN $3 AND $4
+          AND u.longitude BETWEEN $5 AND $6
+          AND u.id <> $7
+          AND ` + moderation.NotBlockedSQL("u.id", "$7") + `
+          AND COALESCE(ps.show_city, TRUE)
+    ) nearby
+    WHERE distance_km <= $8
+      AND ($9::float8 IS NULL OR (distance_km, id) > ($9, $10))
+    ORDER BY distance_km, id
+    LIMIT $11
+    `
+
+	rows, err := s.db.Query(query, latitude, longitude, box.MinLat, box.MaxLat, box.MinLon, box.MaxLon, viewerID, radiusKm,
+		page.AfterValue(), page.AfterID(), page.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error getting nearby users: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var distance float64
+
+		u, err := scanPublicUser(rows, &distance)
 		if err != nil {
-			return nil, fmt.Errorf("error scanning user: %w", err)
+			return nil, err
 		}
 
-		if path.Valid {
-			u.UserPicture = path.String
+		u.DistanceKm = &distance
+		users = append(users, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return utils.NewPage(users, page.Limit, func(u *types.PublicUser) utils.Cursor {
+		return utils.Cursor{Value: u.DistanceKm, ID: u.ID}
+	}), nil
+}
+
+// SearchUsers runs a full-text search over the names and, unless hidden by
+// the owner, the descriptions of active users. Results come by rank and then
+// id, starting after (afterRank, afterID) when afterRank is set. Users the
+// viewer blocked are left out.
+func (s *Store) SearchUsers(viewerID int, text string, filters types.SearchFilters, afterRank *float64, afterID int, limit int) ([]*types.SearchResult, error) {
+	results := []*types.SearchResult{}
+	query := `
+    SELECT id, name, surname, email, status, description, postal_code, city, state, cpf, role_id, points, birth_date, created_at, updated_at, is_verified, path,
+           show_city, show_state, show_description, show_points, show_birth_date, rank,
+           CASE WHEN description_match
+                THEN ts_headline('portuguese_unaccent', description, websearch_to_tsquery('portuguese_unaccent', $1), '` + types.SearchHeadlineOptions + `')
+                ELSE ts_headline('portuguese_unaccent', name || ' ' || surname, websearch_to_tsquery('portuguese_unaccent', $1), '` + types.SearchHeadlineOptions + `')
+           END AS snippet
+    FROM (
+        SELECT u.id, u.name, u.surname, u.email, u.status, u.description, u.postal_code, u.city, u.state, u.cpf, u.role_id, u.points, u.birth_date, u.created_at, u.updated_at, u.is_verified, pp.path,
+               ps.show_city, ps.show_state, ps.show_description, ps.show_points, ps.show_birth_date,
+               ts_rank(
+                   u.name_search_vector || CASE WHEN COALESCE(ps.show_description, TRUE) THEN u.description_search_vector ELSE ''::tsvector END,
+                   websearch_to_tsquery('portuguese_unaccent', $1)
+               )::float8 AS rank,
+               COALESCE(ps.show_description, TRUE) AND u.description_search_vector @@ websearch_to_tsquery('portuguese_unaccent', $1) AS description_match
+        FROM users u
+        LEFT JOIN profile_pictures pp ON u.id = pp.user_id
+        LEFT JOIN privacy_settings ps ON u.id = ps.user_id
+        WHERE (u.name_search_vector @@ websearch_to_tsquery('portuguese_unaccent', $1)
+               OR (COALESCE(ps.show_description, TRUE) AND u.description_search_vector @@ websearch_to_tsquery('portuguese_unaccent', $1)))
+          AND u.status = $2
+          AND ($3 = '' OR (u.city = $3 AND COALESCE(ps.show_city, TRUE)))
+          AND ($4 = '' OR (u.state = $4 AND COALESCE(ps.show_state, TRUE)))
+          AND ($5 = 0 OR u.role_id = $5)
+          AND ` + moderation.NotBlockedSQL("u.id", "$9") + `
+    ) ranked
+    WHERE $6::float8 IS NULL OR rank < $6 OR (rank = $6 AND id > $7)
+    ORDER BY rank DESC, id
+    LIMIT $8
+    `
+
+	rows, err := s.db.Query(query, text, types.StatusActive, filters.City, filters.State, filters.RoleID, afterRank, afterID, limit, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("error searching users: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		result := &types.SearchResult{Type: types.SearchResultUser}
+
+		u, err := scanPublicUser(rows, &result.Rank, &result.Snippet)
+		if err != nil {
+			return nil, err
 		}
 
-		users = append(users, &u)
+		result.User = u
+		results = append(results, result)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return users, nil
+	return results, nil
+}
+
+// scanPublicUser reads a user row followed by its profile picture and privacy
+// settings columns, plus any extra columns selected after them.
+func scanPublicUser(rows *sql.Rows, extra ...any) (*types.PublicUser, error) {
+	var u types.User
+	var path sql.NullString
+	var showCity, showState, showDescription, showPoints, showBirthDate sql.NullBool
+
+	dest := []any{&u.ID, &u.Name, &u.Surname, &u.Email, &u.Status, &u.Description, &u.PostalCode, &u.City, &u.State, &u.CPF, &u.RoleID, &u.Points, &u.BirthDate, &u.CreatedAt, &u.UpdatedAt, &u.IsVerified, &path,
+		&showCity, &showState, &showDescription, &showPoints, &showBirthDate}
+
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return nil, fmt.Errorf("error scanning user: %w", err)
+	}
+
+	if path.Valid {
+		u.UserPicture = path.String
+	}
+
+	settings := DefaultPrivacySettings()
+	if showCity.Valid {
+		settings = &types.PrivacySettings{
+			ShowCity:        showCity.Bool,
+			ShowState:       showState.Bool,
+			ShowDescription: showDescription.Bool,
+			ShowPoints:      showPoints.Bool,
+			ShowBirthDate:   showBirthDate.Bool,
+		}
+	}
+
+	return PublicProfile(&u.UserWithoutPassword, settings), nil
+}
+
+func (s *Store) GetFollowCounts(userID int) (*types.FollowCounts, error) {
+	query := `
+	SELECT
+		(SELECT COUNT(*) FROM follows WHERE followee_id = $1),
+		(SELECT COUNT(*) FROM follows WHERE follower_id = $1)
+	`
+	var counts types.FollowCounts
+	if err := s.db.QueryRow(query, userID).Scan(&counts.Followers, &counts.Following); err != nil {
+		return nil, fmt.Errorf("error getting follow counts: %w", err)
+	}
+
+	return &counts, nil
+}
+
+func (s *Store) IsFollowing(followerID int, followeeID int) (bool, error) {
+	var following bool
+	query := `SELECT EXISTS (SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2)`
+
+	if err := s.db.QueryRow(query, followerID, followeeID).Scan(&following); err != nil {
+		return false, fmt.Errorf("error checking follow: %w", err)
+	}
+
+	return following, nil
+}
+
+// IsBlocked reports whether blockerID blocked blockedID.
+func (s *Store) IsBlocked(blockerID int, blockedID int) (bool, error) {
+	var blocked bool
+	query := `SELECT EXISTS (SELECT 1 FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2)`
+
+	if err := s.db.QueryRow(query, blockerID, blockedID).Scan(&blocked); err != nil {
+		return false, fmt.Errorf("error checking block: %w", err)
+	}
+
+	return blocked, nil
+}
+
+func (s *Store) IsMuted(muterID int, mutedID int) (bool, error) {
+	var muted bool
+	query := `SELECT EXISTS (SELECT 1 FROM user_mutes WHERE muter_id = $1 AND muted_id = $2)`
+
+	if err := s.db.QueryRow(query, muterID, mutedID).Scan(&muted); err != nil {
+		return false, fmt.Errorf("error checking mute: %w", err)
+	}
+
+	return muted, nil
+}
+
+// GetPrivacySettings returns the defaults for users that never saved any.
+func (s *Store) GetPrivacySettings(userID int) (*types.PrivacySettings, error) {
+	query := `
+	SELECT show_city, show_state, show_description, show_points, show_birth_date, show_on_leaderboard
+	FROM privacy_settings
+	WHERE user_id = $1
+	`
+	var ps types.PrivacySettings
+	err := s.db.QueryRow(query, userID).Scan(&ps.ShowCity, &ps.ShowState, &ps.ShowDescription, &ps.ShowPoints, &ps.ShowBirthDate, &ps.ShowOnLeaderboard)
+
+	if err == sql.ErrNoRows {
+		return DefaultPrivacySettings(), nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting privacy settings: %w", err)
+	}
+
+	return &ps, nil
+}
+
+func (s *Store) UpdatePrivacySettings(userID int, settings *types.PrivacySettings) (*types.PrivacySettings, error) {
+	query := `
+	INSERT INTO privacy_settings (user_id, show_city, show_state, show_description, show_points, show_birth_date, show_on_leaderboard)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (user_id) DO UPDATE
+	SET show_city = EXCLUDED.show_city, show_state = EXCLUDED.show_state, show_description = EXCLUDED.show_description,
+	    show_points = EXCLUDED.show_points, show_birth_date = EXCLUDED.show_birth_date,
+	    show_on_leaderboard = EXCLUDED.show_on_leaderboard, updated_at = NOW()
+	RETURNING show_city, show_state, show_description, show_points, show_birth_date, show_on_leaderboard
+	`
+	var ps types.PrivacySettings
+	err := s.db.QueryRow(query, userID, settings.ShowCity, settings.ShowState, settings.ShowDescription, settings.ShowPoints, settings.ShowBirthDate, settings.ShowOnLeaderboard).
+		Scan(&ps.ShowCity, &ps.ShowState, &ps.ShowDescription, &ps.ShowPoints, &ps.ShowBirthDate, &ps.ShowOnLeaderboard)
+
+	if err != nil {
+		return nil, fmt.Errorf("error updating privacy settings: %w", err)
+	}
+
+	return &ps, nil
 }
 
 func (s *Store) GetAllCities() ([]string, error) {
@@ -210,7 +573,7 @@ func (s *Store) GetAllCities() ([]string, error) {
 
 func ScanRowIntoUser(row *sql.Row) (*types.User, error) {
 	var u types.User
-	err := row.Scan(&u.ID, &u.Name, &u.Surname, &u.Email, &u.Password, &u.Status, &u.Description, &u.PostalCode, &u.City, &u.State, &u.CPF, &u.RoleID, &u.Points, &u.BirthDate, &u.CreatedAt, &u.UpdatedAt)
+	err := row.Scan(&u.ID, &u.Name, &u.Surname, &u.Email, &u.Password, &u.Status, &u.Description, &u.PostalCode, &u.City, &u.State, &u.CPF, &u.RoleID, &u.Points, &u.BirthDate, &u.CreatedAt, &u.UpdatedAt, &u.CityIBGECode, &u.Latitude, &u.Longitude, &u.ReferredBy, &u.IsVerified)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -224,7 +587,7 @@ func ScanRowIntoUser(row *sql.Row) (*types.User, error) {
 
 func ScanRowsIntoUser(rows *sql.Rows) (*types.User, error) {
 	var u types.User
-	err := rows.Scan(&u.ID, &u.Name, &u.Surname, &u.Email, &u.Password, &u.Status, &u.Description, &u.PostalCode, &u.City, &u.State, &u.CPF, &u.RoleID, &u.Points, &u.BirthDate, &u.CreatedAt, &u.UpdatedAt)
+	err := rows.Scan(&u.ID, &u.Name, &u.Surname, &u.Email, &u.Password, &u.Status, &u.Description, &u.PostalCode, &u.City, &u.State, &u.CPF, &u.RoleID, &u.Points, &u.BirthDate, &u.CreatedAt, &u.UpdatedAt, &u.CityIBGECode, &u.Latitude, &u.Longitude, &u.ReferredBy, &u.IsVerified)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
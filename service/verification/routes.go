@@ -0,0 +1,357 @@
+package verification
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/alissoncorsair/appsolidario-backend/service/auth"
+	"github.com/alissoncorsair/appsolidario-backend/service/mailer"
+	"github.com/alissoncorsair/appsolidario-backend/service/notification"
+	"github.com/alissoncorsair/appsolidario-backend/service/session"
+	"github.com/alissoncorsair/appsolidario-backend/service/user"
+	"github.com/alissoncorsair/appsolidario-backend/storage"
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+	"github.com/go-playground/validator/v10"
+)
+
+// documentExtensions are the file types accepted for both documents.
+var documentExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".pdf":  true,
+}
+
+type Handler struct {
+	store             *Store
+	userStore         *user.Store
+	notificationStore *notification.Store
+	sessionStore      *session.Store
+	storage           *storage.R2Storage
+	mailer            mailer.Mailer
+}
+
+func NewHandler(store *Store, userStore *user.Store, notificationStore *notification.Store, sessionStore *session.Store, storage *storage.R2Storage, mailer mailer.Mailer) *Handler {
+	return &Handler{
+		store:             store,
+		userStore:         userStore,
+		notificationStore: notificationStore,
+		sessionStore:      sessionStore,
+		storage:           storage,
+		mailer:            mailer,
+	}
+}
+
+// HandleSubmit takes the ID document and the proof of address of a payee,
+// as the "id_document" and "address_proof" form files, and queues them for
+// review.
+func (h *Handler) HandleSubmit(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	if u.RoleID != types.RolePayee {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("apenas beneficiários podem solicitar verificação"))
+		return
+	}
+
+	if u.IsVerified {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("sua conta já está verificada"))
+		return
+	}
+
+	latest, err := h.store.GetLatestRequest(u.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if latest != nil && latest.Status == types.VerificationPending {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("você já tem uma solicitação em análise"))
+		return
+	}
+
+	if err := r.ParseMultipartForm(20 << 20); err != nil { // 20 MB
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("failed to parse form: %w", err))
+		return
+	}
+
+	for _, field := range []string{"id_document", "address_proof"} {
+		if err := checkDocument(r, field); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	idDocument, err := h.uploadDocument(r, "id_document")
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	addressProof, err := h.uploadDocument(r, "address_proof")
+	if err != nil {
+		h.deleteDocuments(r, idDocument)
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	request, err := h.store.CreateRequest(u.ID, idDocument, addressProof)
+	if err != nil {
+		h.deleteDocuments(r, idDocument, addressProof)
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if request == nil {
+		h.deleteDocuments(r, idDocument, addressProof)
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("você já tem uma solicitação em análise"))
+		return
+	}
+
+	h.notify(u, request)
+
+	utils.WriteJSON(w, http.StatusCreated, request)
+}
+
+func checkDocument(r *http.Request, field string) error {
+	files := r.MultipartForm.File[field]
+	if len(files) == 0 {
+		return fmt.Errorf("%s is required", field)
+	}
+
+	if !documentExtensions[strings.ToLower(filepath.Ext(files[0].Filename))] {
+		return fmt.Errorf("%s must be a jpg, png or pdf file", field)
+	}
+
+	return nil
+}
+
+// uploadDocument stores the form file and returns its storage key.
+func (h *Handler) uploadDocument(r *http.Request, field string) (string, error) {
+	file, header, err := r.FormFile(field)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s: %w", field, err)
+	}
+	defer file.Close()
+
+	filename, err := h.storage.UploadPrivateFile(r.Context(), file, header.Filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload %s: %w", field, err)
+	}
+
+	return filename, nil
+}
+
+func (h *Handler) deleteDocuments(r *http.Request, filenames ...string) {
+	for _, filename := range filenames {
+		if err := h.storage.DeleteFile(r.Context(), filename); err != nil {
+			log.Printf("failed to delete verification document %s: %v", filename, err)
+		}
+	}
+}
+
+func (h *Handler) HandleGetOwn(w http.ResponseWriter, r *http.Request) {
+	userID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	request, err := h.store.GetLatestRequest(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if request == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("nenhuma solicitação de verificação encontrada"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, request)
+}
+
+// HandleGetQueue is the admin review queue, pending requests unless another
+// status is asked for.
+func (h *Handler) HandleGetQueue(w http.ResponseWriter, r *http.Request) {
+	status := types.VerificationStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "":
+		status = types.VerificationPending
+	case types.VerificationPending, types.VerificationApproved, types.VerificationRejected:
+	default:
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid status, expected pending, approved or rejected"))
+		return
+	}
+
+	page, err := utils.ParsePageRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	queue, err := h.store.GetQueue(status, page)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, queue)
+}
+
+// HandleGetDocument streams one of the documents of a request to an admin.
+// The files are never exposed through a public URL.
+func (h *Handler) HandleGetDocument(w http.ResponseWriter, r *http.Request) {
+	request, ok := h.requestFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var filename string
+	switch r.PathValue("document") {
+	case "id_document":
+		filename = request.IDDocumentPath
+	case "address_proof":
+		filename = request.AddressProofPath
+	default:
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("document not found"))
+		return
+	}
+
+	file, err := h.storage.GetFile(r.Context(), filename)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get file: %w", err))
+		return
+	}
+	defer file.Close()
+
+	contentType := mime.TypeByExtension(filepath.Ext(filename))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	io.Copy(w, file)
+}
+
+func (h *Handler) HandleApprove(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, types.VerificationApproved, nil)
+}
+
+func (h *Handler) HandleReject(w http.ResponseWriter, r *http.Request) {
+	var payload types.RejectVerificationRequest
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	payload.Reason = strings.TrimSpace(payload.Reason)
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	h.review(w, r, types.VerificationRejected, &payload.Reason)
+}
+
+func (h *Handler) review(w http.ResponseWriter, r *http.Request, status types.VerificationStatus, reason *string) {
+	adminID, found := auth.GetUserIDFromContext(r.Context())
+	if !found {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+
+	request, ok := h.requestFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	reviewed, err := h.store.Review(request.ID, adminID, status, reason)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if reviewed == nil {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("this request was already reviewed"))
+		return
+	}
+
+	payee, err := h.userStore.GetUserByID(reviewed.UserID)
+	if err != nil {
+		log.Printf("failed to get user %d to notify about verification: %v", reviewed.UserID, err)
+	} else if payee != nil {
+		h.notify(payee, reviewed)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, reviewed)
+}
+
+func (h *Handler) requestFromPath(w http.ResponseWriter, r *http.Request) (*types.VerificationRequest, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid verification request ID"))
+		return nil, false
+	}
+
+	request, err := h.store.GetRequestByID(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	if request == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("verification request not found"))
+		return nil, false
+	}
+
+	return request, true
+}
+
+var notificationTypes = map[types.VerificationStatus]types.Type{
+	types.VerificationPending:  types.TypeVerificationSubmitted,
+	types.VerificationApproved: types.TypeVerificationApproved,
+	types.VerificationRejected: types.TypeVerificationRejected,
+}
+
+// notify tells the payee about the new state of their request, in the app
+// and by email. Failures are only logged, the state change already happened.
+func (h *Handler) notify(payee *types.User, request *types.VerificationRequest) {
+	_, err := h.notificationStore.CreateNotification(&types.Notification{
+		UserID:     payee.ID,
+		FromUserID: payee.ID,
+		Type:       notificationTypes[request.Status],
+		ResourceID: request.ID,
+		IsRead:     false,
+	})
+
+	if err != nil {
+		log.Printf("failed to create verification notification: %v", err)
+	}
+
+	if err := h.mailer.SendVerificationStatusEmail(payee, request); err != nil {
+		log.Printf("failed to send verification status email: %v", err)
+	}
+}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("POST /me/verification", auth.WithJWTAuth(h.HandleSubmit, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /me/verification", auth.WithJWTAuth(h.HandleGetOwn, h.userStore, h.sessionStore))
+	router.HandleFunc("GET /admin/verifications", auth.WithJWTAuth(auth.WithRole(h.HandleGetQueue, types.RoleAdmin), h.userStore, h.sessionStore))
+	router.HandleFunc("GET /admin/verifications/{id}/documents/{document}", auth.WithJWTAuth(auth.WithRole(h.HandleGetDocument, types.RoleAdmin), h.userStore, h.sessionStore))
+	router.HandleFunc("POST /admin/verifications/{id}/approve", auth.WithJWTAuth(auth.WithRole(h.HandleApprove, types.RoleAdmin), h.userStore, h.sessionStore))
+	router.HandleFunc("POST /admin/verifications/{id}/reject", auth.WithJWTAuth(auth.WithRole(h.HandleReject, types.RoleAdmin), h.userStore, h.sessionStore))
+}
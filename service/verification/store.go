@@ -0,0 +1,145 @@
+package verification
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/alissoncorsair/appsolidario-backend/types"
+	"github.com/alissoncorsair/appsolidario-backend/utils"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+const requestColumns = `vr.id, vr.user_id, vr.status, vr.id_document_path, vr.address_proof_path, vr.rejection_reason, vr.reviewed_by, vr.reviewed_at, vr.created_at, vr.updated_at`
+
+func scanRequest(scanner interface{ Scan(...any) error }, extra ...any) (*types.VerificationRequest, error) {
+	var vr types.VerificationRequest
+	dest := []any{&vr.ID, &vr.UserID, &vr.Status, &vr.IDDocumentPath, &vr.AddressProofPath, &vr.RejectionReason, &vr.ReviewedBy, &vr.ReviewedAt, &vr.CreatedAt, &vr.UpdatedAt}
+
+	err := scanner.Scan(append(dest, extra...)...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error scanning verification request: %w", err)
+	}
+
+	return &vr, nil
+}
+
+// CreateRequest returns nil when the user already has a request waiting for
+// review.
+func (s *Store) CreateRequest(userID int, idDocumentPath string, addressProofPath string) (*types.VerificationRequest, error) {
+	query := `
+	INSERT INTO verification_requests AS vr (user_id, id_document_path, address_proof_path)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id) WHERE status = 'pending' DO NOTHING
+	RETURNING ` + requestColumns
+
+	return scanRequest(s.db.QueryRow(query, userID, idDocumentPath, addressProofPath))
+}
+
+func (s *Store) GetRequestByID(id int) (*types.VerificationRequest, error) {
+	query := `SELECT ` + requestColumns + ` FROM verification_requests vr WHERE vr.id = $1`
+
+	return scanRequest(s.db.QueryRow(query, id))
+}
+
+// GetLatestRequest returns the last request the user submitted, if any.
+func (s *Store) GetLatestRequest(userID int) (*types.VerificationRequest, error) {
+	query := `
+	SELECT ` + requestColumns + `
+	FROM verification_requests vr
+	WHERE vr.user_id = $1
+	ORDER BY vr.created_at DESC, vr.id DESC
+	LIMIT 1
+	`
+
+	return scanRequest(s.db.QueryRow(query, userID))
+}
+
+// GetQueue lists the requests with the given status along with who sent
+// them, oldest first so the review queue is worked in order.
+func (s *Store) GetQueue(status types.VerificationStatus, page utils.PageRequest) (*utils.Page[*types.VerificationQueueEntry], error) {
+	query := `
+	SELECT ` + requestColumns + `,
+	       u.id, u.name, u.surname, u.email, u.cpf, u.city, u.state, u.birth_date, u.created_at
+	FROM verification_requests vr
+	JOIN users u ON vr.user_id = u.id
+	WHERE vr.status = $1
+	  AND ($2::timestamp IS NULL OR (vr.created_at, vr.id) > ($2, $3))
+	ORDER BY vr.created_at, vr.id
+	LIMIT $4
+	`
+	rows, err := s.db.Query(query, status, page.AfterTime(), page.AfterID(), page.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error getting verification queue: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*types.VerificationQueueEntry{}
+	for rows.Next() {
+		var u types.VerificationApplicant
+		vr, err := scanRequest(rows, &u.ID, &u.Name, &u.Surname, &u.Email, &u.CPF, &u.City, &u.State, &u.BirthDate, &u.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &types.VerificationQueueEntry{VerificationRequest: vr, User: u})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return utils.NewPage(entries, page.Limit, func(e *types.VerificationQueueEntry) utils.Cursor {
+		return utils.Cursor{Time: &e.CreatedAt, ID: e.ID}
+	}), nil
+}
+
+// Review approves or rejects a pending request, flagging the user as
+// verified on approval. It returns nil when the request was already
+// reviewed.
+func (s *Store) Review(id int, reviewerID int, status types.VerificationStatus, reason *string) (*types.VerificationRequest, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	UPDATE verification_requests vr
+	SET status = $1, rejection_reason = $2, reviewed_by = $3, reviewed_at = NOW(), updated_at = NOW()
+	WHERE vr.id = $4 AND vr.status = $5
+	RETURNING ` + requestColumns
+
+	request, err := scanRequest(tx.QueryRow(query, status, reason, reviewerID, id, types.VerificationPending))
+	if err != nil {
+		return nil, err
+	}
+
+	if request == nil {
+		return nil, nil
+	}
+
+	if status == types.VerificationApproved {
+		if _, err := tx.Exec("UPDATE users SET is_verified = TRUE, updated_at = NOW() WHERE id = $1", request.UserID); err != nil {
+			return nil, fmt.Errorf("error verifying user: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return request, nil
+}
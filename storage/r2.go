@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/alissoncorsair/appsolidario-backend/config"
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -13,6 +14,11 @@ import (
 	"github.com/google/uuid"
 )
 
+// PrivatePrefix namespaces the files that are only served by authorized
+// routes, such as verification documents and data exports. Public photos
+// are stored at the root of the bucket.
+const PrivatePrefix = "private/"
+
 type R2Storage struct {
 	client     *s3.Client
 	bucketName string
@@ -46,16 +52,41 @@ func NewR2Storage(accountID, bucketName string) (*R2Storage, error) {
 func (s *R2Storage) UploadFile(ctx context.Context, file io.Reader, filename string) (string, string, error) {
 	uniqueFilename := uuid.New().String() + "-" + filename
 
+	if err := s.put(ctx, file, uniqueFilename); err != nil {
+		return "", "", err
+	}
+
+	return s.generateFileURL(uniqueFilename), uniqueFilename, nil
+}
+
+// UploadPrivateFile stores the file under PrivatePrefix and returns its key.
+func (s *R2Storage) UploadPrivateFile(ctx context.Context, file io.Reader, filename string) (string, error) {
+	key := PrivatePrefix + uuid.New().String() + "-" + filename
+
+	if err := s.put(ctx, file, key); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+func (s *R2Storage) put(ctx context.Context, file io.Reader, key string) error {
 	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket: aws.String(s.bucketName),
-		Key:    aws.String(uniqueFilename),
+		Key:    aws.String(key),
 		Body:   file,
 	})
 	if err != nil {
-		return "", "", fmt.Errorf("failed to upload file: %w", err)
+		return fmt.Errorf("failed to upload file: %w", err)
 	}
 
-	return s.generateFileURL(uniqueFilename), uniqueFilename, nil
+	return nil
+}
+
+// IsPublic reports whether key is a public photo, which are the only files
+// that may be served without authorization.
+func IsPublic(key string) bool {
+	return key != "" && !strings.HasPrefix(key, PrivatePrefix) && !strings.Contains(key, "/")
 }
 
 func (s *R2Storage) GetFile(ctx context.Context, filename string) (io.ReadCloser, error) {
@@ -7,27 +7,42 @@ import (
 
 // Config holds the configuration details for the database connection.
 type Config struct {
-	Host                          string
-	Port                          int
-	PostgresPassword              string
-	PostgresUser                  string
-	PostgresDB                    string
-	SSLMode                       string
-	JWTExpirationInSeconds        int64
-	JWTRefreshExpirationInSeconds int64
-	JWTSecret                     string
-	SendgridApiKey                string
-	EmailFrom                     string
-	EmailFromName                 string
-	EmailVerifyUrl                string
-	R2AccountID                   string
-	R2BucketName                  string
-	R2AccessKeyID                 string
-	R2AccessKeySecret             string
-	DevMode                       bool
-	PGCert                        string
-	MercadoPagoAccessToken        string
-	MercadoPagoWebhookSecret      string
+	Host                             string
+	Port                             int
+	PostgresPassword                 string
+	PostgresUser                     string
+	PostgresDB                       string
+	SSLMode                          string
+	JWTExpirationInSeconds           int64
+	JWTRefreshExpirationInSeconds    int64
+	JWTKeysDir                       string
+	JWTSigningKeyID                  string
+	SendgridApiKey                   string
+	EmailFrom                        string
+	EmailFromName                    string
+	EmailVerifyUrl                   string
+	EmailResetPasswordUrl            string
+	PasswordResetExpirationInSeconds int64
+	EmailUnlockAccountUrl            string
+	LoginMaxAttemptsPerCPF           int64
+	LoginMaxAttemptsPerIP            int64
+	LoginBackoffAfterAttempts        int64
+	LoginBackoffBaseSeconds          int64
+	LoginLockoutDurationInSeconds    int64
+	AccountDeletionGraceInSeconds    int64
+	R2AccountID                      string
+	R2BucketName                     string
+	R2AccessKeyID                    string
+	R2AccessKeySecret                string
+	DevMode                          bool
+	PGCert                           string
+	MercadoPagoAccessToken           string
+	MercadoPagoWebhookSecret         string
+	UnverifiedPayeeMaxDonation       int64
+	UnverifiedPayeeMonthlyLimit      int64
+	// TrustedProxies are the addresses or CIDR ranges of the proxies in front
+	// of the API, whose X-Forwarded-For headers are honored.
+	TrustedProxies []string
 }
 
 // UserRole defines the role of a user.
@@ -36,6 +51,7 @@ type UserRole int
 const (
 	RolePayee UserRole = 1
 	RolePayer UserRole = 2
+	RoleAdmin UserRole = 3
 )
 
 // UserStatus defines the status of a user.
@@ -53,6 +69,13 @@ const (
 	TokenTypeRefresh TokenType = "refresh"
 	TokenTypeReset   TokenType = "reset"
 	TokenTypeVerify  TokenType = "verify"
+	TokenTypeUnlock  TokenType = "unlock"
+	// issued after the password check for accounts with 2FA, it can only be
+	// exchanged for a session at /login/mfa
+	TokenTypeMFAPending TokenType = "mfa_pending"
+	// sent to a new address to confirm it, it is single use and carries the
+	// address in Token.Email
+	TokenTypeEmailChange TokenType = "email_change"
 )
 
 type TransactionStatus int
@@ -65,13 +88,20 @@ const (
 
 // User represents the user entity.
 type UserWithoutPassword struct {
-	ID          int    `json:"id"`
-	Name        string `json:"name" validate:"required,min=3,max=100"`
-	Surname     string `json:"surname" validate:"required,min=3,max=100"`
-	Email       string `json:"email" validate:"required,email"`
-	PostalCode  string `json:"postal_code" validate:"required,min=8,max=9"`
-	City        string `json:"city" validate:"required,max=100"`
-	UserPicture string `json:"user_picture"`
+	ID           int    `json:"id"`
+	Name         string `json:"name" validate:"required,min=3,max=100"`
+	Surname      string `json:"surname" validate:"required,min=3,max=100"`
+	Email        string `json:"email" validate:"required,email"`
+	PostalCode   string `json:"postal_code" validate:"required,min=8,max=9"`
+	City         string `json:"city" validate:"required,max=100"`
+	CityIBGECode *int   `json:"city_ibge_code,omitempty"`
+	ReferredBy   *int   `json:"referred_by,omitempty"`
+	IsVerified   bool   `json:"is_verified"`
+	// Latitude and Longitude approximate where the user lives (the center of
+	// their city) and are only exposed to the owner.
+	Latitude    *float64 `json:"latitude,omitempty"`
+	Longitude   *float64 `json:"longitude,omitempty"`
+	UserPicture string   `json:"user_picture"`
 	// Street           string     `json:"street" validate:"required,max=255"`
 	State       string     `json:"state" validate:"required,max=100"`
 	Status      UserStatus `json:"status" validate:"required,oneof=0 1"` // 0 for inactive, 1 for active
@@ -86,16 +116,116 @@ type UserWithoutPassword struct {
 
 type User struct {
 	UserWithoutPassword
-	Password string `json:"password,omitempty"`
+	Password string `json:"-"`
 }
 
 type Token struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id"`
-	Token     string    `json:"token"`
-	Type      TokenType `json:"type"`
-	CreatedAt time.Time `json:"created_at"`
-	ExpiresAt time.Time `json:"expires_at"`
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	Token     string     `json:"token"`
+	Type      TokenType  `json:"type"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	SessionID *int       `json:"session_id,omitempty"`
+	Email     *string    `json:"-"`
+}
+
+type Session struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	DeviceName string     `json:"device_name"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	Current    bool       `json:"current"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type LoginAttempt struct {
+	Key           string     `json:"key"`
+	Failures      int64      `json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	BlockedUntil  *time.Time `json:"blocked_until,omitempty"`
+}
+
+type UserMFA struct {
+	UserID       int        `json:"user_id"`
+	Secret       string     `json:"-"`
+	Enabled      bool       `json:"enabled"`
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	EnabledAt    *time.Time `json:"enabled_at,omitempty"`
+}
+
+type DataExportStatus string
+
+const (
+	DataExportPending    DataExportStatus = "pending"
+	DataExportProcessing DataExportStatus = "processing"
+	DataExportReady      DataExportStatus = "ready"
+	DataExportFailed     DataExportStatus = "failed"
+)
+
+type DataExport struct {
+	ID          int              `json:"id"`
+	UserID      int              `json:"user_id"`
+	Status      DataExportStatus `json:"status"`
+	Filename    string           `json:"-"`
+	Error       string           `json:"error,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
+}
+
+// PublicUser is what other users can see of a profile. Fields the owner
+// chose to hide in their privacy settings are left out.
+type PublicUser struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Surname     string     `json:"surname"`
+	UserPicture string     `json:"user_picture"`
+	City        string     `json:"city,omitempty"`
+	State       string     `json:"state,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	RoleID      UserRole   `json:"role_id"`
+	IsVerified  bool       `json:"is_verified"`
+	Points      *int       `json:"points,omitempty"`
+	BirthDate   *time.Time `json:"birth_date,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	// DistanceKm is only set by the nearby search.
+	DistanceKm *float64 `json:"distance_km,omitempty"`
+}
+
+type FollowCounts struct {
+	Followers int `json:"followers_count"`
+	Following int `json:"following_count"`
+}
+
+type Follow struct {
+	FollowerID int       `json:"follower_id"`
+	FolloweeID int       `json:"followee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type PrivacySettings struct {
+	ShowCity          bool `json:"show_city"`
+	ShowState         bool `json:"show_state"`
+	ShowDescription   bool `json:"show_description"`
+	ShowPoints        bool `json:"show_points"`
+	ShowBirthDate     bool `json:"show_birth_date"`
+	ShowOnLeaderboard bool `json:"show_on_leaderboard"`
+}
+
+type AccountDeletion struct {
+	UserID       int       `json:"user_id"`
+	RequestedAt  time.Time `json:"requested_at"`
+	ScheduledFor time.Time `json:"scheduled_for"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
 }
 
 type UserStore interface {
@@ -103,6 +233,12 @@ type UserStore interface {
 	GetUserByID(id int) (*User, error)
 	CreateUser(user *User) (*User, error)
 }
+
+type SessionStore interface {
+	IsSessionActive(id int) (bool, error)
+	TouchSession(id int) error
+	RevokeUserSessions(userID int) error
+}
 type ProfilePicture struct {
 	ID        int       `json:"id"`
 	UserID    int       `json:"user_id"`
@@ -123,30 +259,131 @@ type RegisterUserRequest struct {
 	CPF         string      `json:"cpf" validate:"required,min=11,max=14"`
 	RoleID      json.Number `json:"role_id,string" validate:"required"`
 	BirthDate   string      `json:"birth_date" validate:"required"`
+	// ReferrerID is the user who invited this one, if any.
+	ReferrerID *int `json:"referrer_id" validate:"omitempty,gt=0"`
 }
 
-type LoginUserRequest struct {
-	CPF      string `json:"cpf" validate:"required,min=11,max=14"`
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
 	Password string `json:"password" validate:"required,min=6"`
 }
 
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type UnlockAccountRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type MFALoginRequest struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code"`
+	DeviceName   string `json:"device_name" validate:"omitempty,max=100"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required,len=6"`
+}
+
+type DisableMFARequest struct {
+	Code         string `json:"code" validate:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// UpdateProfileRequest only changes the fields that are present.
+type UpdateProfileRequest struct {
+	Name        *string `json:"name" validate:"omitempty,min=3,max=100"`
+	Surname     *string `json:"surname" validate:"omitempty,min=3,max=100"`
+	PostalCode  *string `json:"postal_code" validate:"omitempty,min=8,max=9"`
+	City        *string `json:"city" validate:"omitempty,min=1,max=100"`
+	State       *string `json:"state" validate:"omitempty,min=1,max=100"`
+	Description *string `json:"description" validate:"omitempty,max=1000"`
+}
+
+// AuditLogEntry records a change to a sensitive field of a user. Values are
+// left empty for secrets such as the password.
+type AuditLogEntry struct {
+	Field    string
+	OldValue string
+	NewValue string
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" validate:"required,email,max=100"`
+	Password string `json:"password" validate:"required"`
+}
+
+type LoginUserRequest struct {
+	CPF        string `json:"cpf" validate:"required,min=11,max=14"`
+	Password   string `json:"password" validate:"required,min=6"`
+	DeviceName string `json:"device_name" validate:"omitempty,max=100"`
+}
+
 type Post struct {
-	ID          int        `json:"id"`
-	UserID      int        `json:"user_id"`
-	AuthorName  string     `json:"author_name"`
-	UserCity    string     `json:"user_city"`
-	UserPicture string     `json:"user_picture"`
-	Comments    []*Comment `json:"comments"`
-	Description string     `json:"description" validate:"required"`
-	Photos      []string   `json:"photos"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID             int        `json:"id"`
+	UserID         int        `json:"user_id"`
+	AuthorName     string     `json:"author_name"`
+	AuthorVerified bool       `json:"author_verified"`
+	UserCity       string     `json:"user_city"`
+	UserPicture    string     `json:"user_picture"`
+	Comments       []*Comment `json:"comments"`
+	// CommentsNextCursor is set when the post has more comments than the
+	// ones embedded.
+	CommentsNextCursor *string   `json:"comments_next_cursor,omitempty"`
+	Description        string    `json:"description" validate:"required"`
+	Photos             []string  `json:"photos"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+	// EditedAt is set once the post was edited, see PostRevision.
+	EditedAt *time.Time `json:"edited_at,omitempty"`
+	// DistanceKm is only set by the nearby search.
+	DistanceKm *float64 `json:"distance_km,omitempty"`
+	// Donations is only set on a single post and the city feed.
+	Donations *PostDonations `json:"donations,omitempty"`
+	Reactions *Reactions     `json:"reactions,omitempty"`
+}
+
+// PostDonations sums the confirmed donations made from a post.
+type PostDonations struct {
+	RaisedAmount float64 `json:"raised_amount"`
+	Donors       int     `json:"donors_count"`
 }
 
 type PostPhoto struct {
 	ID        int       `json:"id"`
 	PostID    int       `json:"post_id"`
 	Filename  string    `json:"filename"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PostRevision is a version of a post replaced by an edit, created at the
+// time of the edit.
+type PostRevision struct {
+	ID          int       `json:"id"`
+	PostID      int       `json:"post_id"`
+	Description string    `json:"description"`
+	Photos      []string  `json:"photos"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CommentRevision is a version of a comment replaced by an edit, created at
+// the time of the edit.
+type CommentRevision struct {
+	ID        int       `json:"id"`
+	CommentID int       `json:"comment_id"`
+	Content   string    `json:"content"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -163,6 +400,30 @@ type Comment struct {
 	Content     string    `json:"content" validate:"required"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	// EditedAt is set once the comment was edited, see CommentRevision.
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	Reactions *Reactions `json:"reactions,omitempty"`
+}
+
+type ReactionKind string
+
+const (
+	ReactionHeart ReactionKind = "heart" // ❤️
+	ReactionPray  ReactionKind = "pray"  // 🙏
+	ReactionClap  ReactionKind = "clap"  // 👏
+)
+
+// Reactions sums up the reactions to a post or comment as seen by the user
+// asking for it.
+type Reactions struct {
+	Counts map[ReactionKind]int `json:"counts"`
+	Total  int                  `json:"total"`
+	// ViewerReaction is the viewer's own reaction, if any.
+	ViewerReaction *ReactionKind `json:"viewer_reaction"`
+}
+
+type ReactRequest struct {
+	Kind ReactionKind `json:"kind" validate:"required,oneof=heart pray clap"`
 }
 
 type CommentWithUserPicture struct {
@@ -180,17 +441,40 @@ type Transaction struct {
 	Description string            `json:"description" validate:"required"`
 	CreatedAt   time.Time         `json:"created_at"`
 	UpdatedAt   time.Time         `json:"updated_at"`
+	// CampaignID is set when the donation was made to a campaign.
+	CampaignID *int `json:"campaign_id,omitempty"`
+	// PostID is set when the donation was made from a post.
+	PostID *int `json:"post_id,omitempty"`
 }
 
 type CreateCommentRequest struct {
 	Content string `json:"content" validate:"required"`
 }
 
+type UpdateCommentRequest struct {
+	Content string `json:"content" validate:"required"`
+}
+
 type Type string
 
 const (
 	TypePayment Type = "payment"
-	TypePost    Type = "post"
+	// TypePost notifications tell the author about reactions to their post
+	// or to their comments on a post, with the post as resource. They are
+	// batched while unread, see Notification.Count.
+	TypePost Type = "post"
+	// TypeFollow notifications have the follower as resource.
+	TypeFollow Type = "follow"
+	// TypeBadge notifications have the unlocked user badge as resource.
+	TypeBadge Type = "badge"
+	// The verification notifications have the verification request as
+	// resource.
+	TypeVerificationSubmitted Type = "verification_submitted"
+	TypeVerificationApproved  Type = "verification_approved"
+	TypeVerificationRejected  Type = "verification_rejected"
+	// TypeCampaignCompleted notifications go to the donors of a campaign that
+	// reached its goal, with the campaign as resource.
+	TypeCampaignCompleted Type = "campaign_completed"
 )
 
 type Notification struct {
@@ -202,4 +486,216 @@ type Notification struct {
 	IsRead     bool      `json:"is_read"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
+	// Count is how many events were batched into the notification, which
+	// then has the latest sender and time.
+	Count int `json:"count"`
+}
+
+type SearchResultType string
+
+const (
+	SearchResultPost SearchResultType = "post"
+	SearchResultUser SearchResultType = "user"
+)
+
+// SearchFilters narrow a search down to users, or post authors, of a city,
+// state or role. Empty values don't filter.
+type SearchFilters struct {
+	City   string
+	State  string
+	RoleID UserRole
+}
+
+// SearchHeadlineOptions are the ts_headline options used to build the
+// snippets of the search results.
+const SearchHeadlineOptions = "StartSel=<b>, StopSel=</b>, MaxWords=25, MinWords=10, MaxFragments=2"
+
+// SearchResult holds either a user or a post, with the matched text
+// highlighted in Snippet.
+type SearchResult struct {
+	Type    SearchResultType `json:"type"`
+	Rank    float64          `json:"rank"`
+	Snippet string           `json:"snippet"`
+	User    *PublicUser      `json:"user,omitempty"`
+	Post    *Post            `json:"post,omitempty"`
+}
+
+type PointsEvent string
+
+const (
+	PointsEventDonation      PointsEvent = "donation"
+	PointsEventFirstPost     PointsEvent = "first_post"
+	PointsEventEmailVerified PointsEvent = "email_verified"
+	PointsEventReferral      PointsEvent = "referral"
+	// PointsEventAdjustment holds the points users had before the ledger.
+	PointsEventAdjustment PointsEvent = "adjustment"
+)
+
+type PointsLedgerEntry struct {
+	ID          int         `json:"id"`
+	UserID      int         `json:"user_id"`
+	Event       PointsEvent `json:"event"`
+	ReferenceID int         `json:"reference_id"`
+	Points      int         `json:"points"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// Badge is unlocked once the user's points reach Threshold.
+type Badge struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Threshold   int    `json:"threshold"`
+}
+
+type UserBadge struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	Badge      Badge     `json:"badge"`
+	UnlockedAt time.Time `json:"unlocked_at"`
+}
+
+// LeaderboardEntry is a donor's position in a leaderboard. City and state
+// follow the donor's privacy settings.
+type LeaderboardEntry struct {
+	Rank        int     `json:"rank"`
+	UserID      int     `json:"user_id"`
+	Name        string  `json:"name"`
+	Surname     string  `json:"surname"`
+	UserPicture string  `json:"user_picture"`
+	City        string  `json:"city,omitempty"`
+	State       string  `json:"state,omitempty"`
+	Amount      float64 `json:"amount"`
+	Donations   int     `json:"donations"`
+	Points      int     `json:"points"`
+}
+
+type VerificationStatus string
+
+const (
+	VerificationPending  VerificationStatus = "pending"
+	VerificationApproved VerificationStatus = "approved"
+	VerificationRejected VerificationStatus = "rejected"
+)
+
+// VerificationRequest is a payee's KYC submission. The document paths are
+// storage keys and are only served to admins.
+type VerificationRequest struct {
+	ID               int                `json:"id"`
+	UserID           int                `json:"user_id"`
+	Status           VerificationStatus `json:"status"`
+	IDDocumentPath   string             `json:"-"`
+	AddressProofPath string             `json:"-"`
+	RejectionReason  *string            `json:"rejection_reason,omitempty"`
+	ReviewedBy       *int               `json:"reviewed_by,omitempty"`
+	ReviewedAt       *time.Time         `json:"reviewed_at,omitempty"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+}
+
+// VerificationApplicant is what admins see of the payee behind a request.
+type VerificationApplicant struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Surname   string    `json:"surname"`
+	Email     string    `json:"email"`
+	CPF       string    `json:"cpf"`
+	City      string    `json:"city"`
+	State     string    `json:"state"`
+	BirthDate time.Time `json:"birth_date"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type VerificationQueueEntry struct {
+	*VerificationRequest
+	User VerificationApplicant `json:"user"`
+}
+
+type RejectVerificationRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// ModeratedUser is an entry of the users someone blocked or muted.
+type ModeratedUser struct {
+	UserID      int       `json:"user_id"`
+	Name        string    `json:"name"`
+	Surname     string    `json:"surname"`
+	UserPicture string    `json:"user_picture"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ReportReason string
+
+const (
+	ReportSpam          ReportReason = "spam"
+	ReportHarassment    ReportReason = "harassment"
+	ReportFraud         ReportReason = "fraud"
+	ReportInappropriate ReportReason = "inappropriate"
+	ReportOther         ReportReason = "other"
+)
+
+type ReportStatus string
+
+const (
+	ReportOpen      ReportStatus = "open"
+	ReportResolved  ReportStatus = "resolved"
+	ReportDismissed ReportStatus = "dismissed"
+)
+
+type Report struct {
+	ID         int          `json:"id"`
+	ReporterID int          `json:"reporter_id"`
+	ReportedID int          `json:"reported_id"`
+	Reason     ReportReason `json:"reason"`
+	Details    *string      `json:"details,omitempty"`
+	Status     ReportStatus `json:"status"`
+	ResolvedBy *int         `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time   `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type CreateReportRequest struct {
+	Reason  ReportReason `json:"reason" validate:"required,oneof=spam harassment fraud inappropriate other"`
+	Details *string      `json:"details" validate:"omitempty,max=1000"`
+	// Block also blocks the reported user.
+	Block bool `json:"block"`
+}
+
+type ResolveReportRequest struct {
+	Status ReportStatus `json:"status" validate:"required,oneof=resolved dismissed"`
+}
+
+type CampaignStatus string
+
+const (
+	CampaignOpen      CampaignStatus = "open"
+	CampaignCompleted CampaignStatus = "completed"
+	CampaignExpired   CampaignStatus = "expired"
+)
+
+// Campaign is a fundraising goal of a payee. Progress is the fraction of
+// the goal raised so far, above 1 when the campaign got more than it asked
+// for.
+type Campaign struct {
+	ID           int            `json:"id"`
+	UserID       int            `json:"user_id"`
+	Title        string         `json:"title"`
+	Description  string         `json:"description"`
+	GoalAmount   float64        `json:"goal_amount"`
+	RaisedAmount float64        `json:"raised_amount"`
+	Donations    int            `json:"donations_count"`
+	Progress     float64        `json:"progress"`
+	Status       CampaignStatus `json:"status"`
+	Deadline     time.Time      `json:"deadline"`
+	ClosedAt     *time.Time     `json:"closed_at,omitempty"`
+	Photos       []string       `json:"photos"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+type CreateCampaignRequest struct {
+	Title       string  `json:"title" validate:"required,min=3,max=120"`
+	Description string  `json:"description" validate:"max=5000"`
+	GoalAmount  float64 `json:"goal_amount" validate:"required,gt=0"`
+	Deadline    string  `json:"deadline" validate:"required"`
 }
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	DefaultNearbyRadiusKm = 50
+	MaxNearbyRadiusKm     = 500
+)
+
+// NearbyQuery holds the query parameters of the nearby endpoints.
+type NearbyQuery struct {
+	Latitude  float64
+	Longitude float64
+	RadiusKm  float64
+	Page      PageRequest
+}
+
+// ParseNearbyQuery reads radius_km and the page from the query string.
+// The search is centered on lat/lon when both are given, otherwise on the
+// caller's own coordinates, which are nil when their city is unknown.
+func ParseNearbyQuery(r *http.Request, latitude, longitude *float64) (*NearbyQuery, error) {
+	values := r.URL.Query()
+	query := &NearbyQuery{
+		RadiusKm: DefaultNearbyRadiusKm,
+	}
+
+	if values.Get("lat") != "" || values.Get("lon") != "" {
+		lat, err := strconv.ParseFloat(values.Get("lat"), 64)
+		if err != nil || lat < -90 || lat > 90 {
+			return nil, fmt.Errorf("lat inválida")
+		}
+
+		lon, err := strconv.ParseFloat(values.Get("lon"), 64)
+		if err != nil || lon < -180 || lon > 180 {
+			return nil, fmt.Errorf("lon inválida")
+		}
+
+		latitude, longitude = &lat, &lon
+	}
+
+	if latitude == nil || longitude == nil {
+		return nil, fmt.Errorf("localização desconhecida, informe lat e lon ou atualize a cidade do seu perfil")
+	}
+
+	query.Latitude, query.Longitude = *latitude, *longitude
+
+	if v := values.Get("radius_km"); v != "" {
+		radius, err := strconv.ParseFloat(v, 64)
+		if err != nil || radius <= 0 || radius > MaxNearbyRadiusKm {
+			return nil, fmt.Errorf("radius_km deve estar entre 0 e %d", MaxNearbyRadiusKm)
+		}
+
+		query.RadiusKm = radius
+	}
+
+	page, err := ParsePageRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	query.Page = page
+
+	return query, nil
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Cursor is the position of the last item of a page, in the order of the
+// list it came from. Every list sorts by ID last, to break ties, and fills
+// whichever of the other keys it sorts by before it.
+type Cursor struct {
+	Time  *time.Time `json:"t,omitempty"`
+	Value *float64   `json:"v,omitempty"`
+	Kind  string     `json:"k,omitempty"`
+	ID    int        `json:"i"`
+}
+
+// Encode returns the cursor in the opaque form sent to clients.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("cursor inválido")
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("cursor inválido")
+	}
+
+	return &c, nil
+}
+
+// PageRequest is the page asked by the client. After is nil for the first
+// page.
+type PageRequest struct {
+	Limit int
+	After *Cursor
+}
+
+// AfterTime and AfterValue return the cursor keys, or nil on the first page,
+// ready to be passed as query arguments.
+func (p PageRequest) AfterTime() *time.Time {
+	if p.After == nil {
+		return nil
+	}
+
+	return p.After.Time
+}
+
+func (p PageRequest) AfterValue() *float64 {
+	if p.After == nil {
+		return nil
+	}
+
+	return p.After.Value
+}
+
+func (p PageRequest) AfterID() int {
+	if p.After == nil {
+		return 0
+	}
+
+	return p.After.ID
+}
+
+// ParsePageRequest reads the limit and cursor query parameters.
+func ParsePageRequest(r *http.Request) (PageRequest, error) {
+	values := r.URL.Query()
+	page := PageRequest{Limit: DefaultPageLimit}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > MaxPageLimit {
+			return page, fmt.Errorf("limit deve estar entre 1 e %d", MaxPageLimit)
+		}
+
+		page.Limit = limit
+	}
+
+	if v := values.Get("cursor"); v != "" {
+		after, err := DecodeCursor(v)
+		if err != nil {
+			return page, err
+		}
+
+		page.After = after
+	}
+
+	return page, nil
+}
+
+// Page is the response of every list endpoint. NextCursor is null on the
+// last page.
+type Page[T any] struct {
+	Items      []T     `json:"items"`
+	Limit      int     `json:"limit"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+// NewPage builds a page out of the items fetched by a store, which asks the
+// database for one item more than the limit: if it comes back, there's a next
+// page, starting after the last item kept.
+func NewPage[T any](items []T, limit int, cursor func(T) Cursor) *Page[T] {
+	page := &Page[T]{
+		Items: items,
+		Limit: limit,
+	}
+
+	if len(items) > limit {
+		page.Items = items[:limit]
+
+		next := cursor(page.Items[limit-1]).Encode()
+		page.NextCursor = &next
+	}
+
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+
+	return page
+}
@@ -7,11 +7,14 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"log"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/alissoncorsair/appsolidario-backend/config"
 	"github.com/go-playground/validator/v10"
 )
 
@@ -85,6 +88,72 @@ func ParseDate(date string) (time.Time, error) {
 
 }
 
+var trustedProxies = parseTrustedProxies(config.Envs.TrustedProxies)
+
+// parseTrustedProxies reads addresses and CIDR ranges, skipping invalid ones.
+func parseTrustedProxies(entries []string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Printf("ignoring invalid trusted proxy %q: %v", entry, err)
+			continue
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks
+}
+
+func isTrustedProxy(ip net.IP) bool {
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetClientIP returns the address of the client. X-Forwarded-For is only
+// honored when the request comes from a trusted proxy, and then the client is
+// the rightmost hop that isn't one, as everything left of it can be forged.
+func GetClientIP(r *http.Request) string {
+	client, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		client = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(client)
+	if ip == nil || !isTrustedProxy(ip) {
+		return client
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+
+		client = hop.String()
+		if !isTrustedProxy(hop) {
+			break
+		}
+	}
+
+	return client
+}
+
 func GetHttpClient() *http.Client {
 	return &http.Client{
 		Timeout: 10 * time.Second,